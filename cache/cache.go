@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Cache is a content-addressed key-value file, every Put appends a JSON line
+// so a crash loses at most the last entry, and the later entry of a key wins on Open.
+type Cache struct {
+	lock    sync.Mutex
+	entries map[string]string
+	file    *os.File
+	hits    int
+}
+
+type entry struct {
+	Key   string `json:"k"`
+	Value string `json:"v"`
+}
+
+// DefaultPath returns the cache file under the user cache directory,
+// empty if the directory is unknown.
+func DefaultPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "goTranscriber", "cache.jsonl")
+}
+
+// Key builds a content-addressed key from the kind, the hash of data and the
+// parameters which change the result, e.g. the engine and the language.
+func Key(kind string, data []byte, params ...string) string {
+	sum := sha256.Sum256(data)
+	return kind + ":" + hex.EncodeToString(sum[:]) + ":" + strings.Join(params, ":")
+}
+
+// Open loads the cache file, it's created if not exists
+func Open(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cache{entries: map[string]string{}, file: file}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e entry
+		// A truncated line left by a crash is skipped
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Key != "" {
+			c.entries[e.Key] = e.Value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	// End the truncated line, otherwise the next entry is appended to it
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			file.Write([]byte{'\n'})
+		}
+	}
+	return c, nil
+}
+
+// Get returns the cached value, it's safe to call on nil
+func (c *Cache) Get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	value, ok := c.entries[key]
+	if ok {
+		c.hits++
+	}
+	return value, ok
+}
+
+// Put saves the value and appends it to the file, it's safe to call on nil
+func (c *Cache) Put(key, value string) error {
+	if c == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry{Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if old, ok := c.entries[key]; ok && old == value {
+		return nil
+	}
+	c.entries[key] = value
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+// Len returns the number of entries
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.entries)
+}
+
+// Hits returns how many Get found the value
+func (c *Cache) Hits() int {
+	if c == nil {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.hits
+}
+
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.file.Close()
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "cache.jsonl")
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := Key("asr", []byte("pcm"), "google", "ja")
+	if key != Key("asr", []byte("pcm"), "google", "ja") || key == Key("asr", []byte("pcm"), "google", "en") {
+		t.Fatalf("keys should be content-addressed: %s", key)
+	}
+	if _, ok := c.Get(key); ok {
+		t.Error("empty cache hit")
+	}
+	c.Put(key, "first")
+	c.Put(key, "こんにちは\n二行目")
+	c.Put("other", "value")
+	c.Close()
+
+	// a truncated line left by a crash
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"k":"broken","v":"`)
+	f.Close()
+
+	c, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if v, ok := c.Get(key); !ok || v != "こんにちは\n二行目" {
+		t.Errorf("reloaded %q %v", v, ok)
+	}
+	if _, ok := c.Get("broken"); ok || c.Len() != 2 || c.Hits() != 1 {
+		t.Errorf("len %d hits %d", c.Len(), c.Hits())
+	}
+	c.Put("after", "crash")
+	c.Close()
+	if c, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.Get("after"); v != "crash" {
+		t.Errorf("the entry after the truncated line is lost: %q", v)
+	}
+
+	var none *Cache
+	if _, ok := none.Get(key); ok || none.Put(key, "v") != nil {
+		t.Error("nil cache should be a no-op")
+	}
+}
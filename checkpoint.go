@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MeteorsLiu/goSRT/voice"
+)
+
+// CHECKPOINT_INTERVAL 进度文件的写入间隔
+var CHECKPOINT_INTERVAL = 30 * time.Second
+
+// checkpoint 保存任务进度：人声区域和已经识别完成的字幕，
+// 网络中断后可以用 -resume 继续，只处理没有完成的切片
+type checkpoint struct {
+	File    string           `json:"file"`
+	Lang    string           `json:"lang"`
+	VadMode string           `json:"vad"`
+	Audio   voice.Options    `json:"audio"` // 音轨、声道和预处理选项，不同时人声区域和切片都会变化
+	Regions []voice.Region   `json:"regions"`
+	Done    map[int]Subtitle `json:"done"` // 区域序号 -> 识别成功的字幕
+
+	path  string
+	lock  sync.Mutex
+	dirty bool
+}
+
+// checkpointNameOf 返回视频对应的进度文件，例如 xxx.checkpoint.json
+func checkpointNameOf(filename string) string {
+	prefix := strings.Split(filepath.Base(filename), ".")[0]
+	return filepath.Join(filepath.Dir(filename), prefix+".checkpoint.json")
+}
+
+// audioOptionsOf 去掉不影响人声区域和切片的选项，用于比较两次任务的设置
+func audioOptionsOf(audio voice.Options) voice.Options {
+	audio.SpeechProb = false
+	return audio
+}
+
+func newCheckpoint(filename, lang, vadMode string, audio voice.Options, regions []voice.Region) *checkpoint {
+	return &checkpoint{
+		File:    filename,
+		Lang:    lang,
+		VadMode: vadMode,
+		Audio:   audioOptionsOf(audio),
+		Regions: regions,
+		Done:    map[int]Subtitle{},
+		path:    checkpointNameOf(filename),
+		dirty:   true,
+	}
+}
+
+// loadCheckpoint 读取进度文件，语言、VAD模式或音频选项不同时区域和识别结果都无法复用
+func loadCheckpoint(filename, lang, vadMode string, audio voice.Options) (*checkpoint, error) {
+	path := checkpointNameOf(filename)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no checkpoint to resume: %w", err)
+	}
+	cp := &checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	if cp.Lang != lang {
+		return nil, fmt.Errorf("checkpoint %s is for language %s, not %s", path, cp.Lang, lang)
+	}
+	if cp.VadMode != vadMode {
+		return nil, fmt.Errorf("checkpoint %s is for -vad %s, not %s", path, cp.VadMode, vadMode)
+	}
+	if cp.Audio != audioOptionsOf(audio) {
+		return nil, fmt.Errorf("checkpoint %s was made with other audio options %+v, run without -resume to start over", path, cp.Audio)
+	}
+	if len(cp.Regions) == 0 {
+		return nil, fmt.Errorf("checkpoint %s has no regions", path)
+	}
+	if cp.Done == nil {
+		cp.Done = map[int]Subtitle{}
+	}
+	cp.path = path
+	return cp, nil
+}
+
+// missing 返回没有识别完成的区域序号
+func (c *checkpoint) missing() []int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var ids []int
+	for id := range c.Regions {
+		if _, ok := c.Done[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// add 记录识别成功的字幕，识别失败的区域在 -resume 时重新处理
+func (c *checkpoint) add(sub Subtitle) {
+	if sub.Err != "" {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Done[sub.ID] = sub
+	c.dirty = true
+}
+
+// flush 有变化时写入进度文件，先写临时文件再重命名，避免中断时留下不完整的文件
+func (c *checkpoint) flush() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// autoFlush 每隔 CHECKPOINT_INTERVAL 写入一次进度文件，返回的函数停止写入并做最后一次写入
+func (c *checkpoint) autoFlush() (stop func()) {
+	ticker := time.NewTicker(CHECKPOINT_INTERVAL)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.flush(); err != nil {
+					log.Println("Write checkpoint failed:", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+		wg.Wait()
+		if err := c.flush(); err != nil {
+			log.Println("Write checkpoint failed:", err)
+		}
+	}
+}
+
+// remove 任务成功后删除进度文件
+func (c *checkpoint) remove() {
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		log.Println("Remove checkpoint failed:", err)
+	}
+}
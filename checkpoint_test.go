@@ -0,0 +1,75 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MeteorsLiu/goSRT/voice"
+)
+
+func TestCheckpointNameOf(t *testing.T) {
+	for filename, want := range map[string]string{
+		"video.mp4":              "video.checkpoint.json",
+		"/data/show.s01e01.mkv":  "/data/show.checkpoint.json",
+		filepath.Join("a", "b"):  filepath.Join("a", "b.checkpoint.json"),
+		"/data/dir.v2/movie.mp4": "/data/dir.v2/movie.checkpoint.json",
+	} {
+		if got := checkpointNameOf(filename); got != want {
+			t.Errorf("checkpointNameOf(%s) = %s, want %s", filename, got, want)
+		}
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "video.mp4")
+	audio := voice.Options{AudioStream: 1, Channel: "FC", HighPass: 80}
+	regions := []voice.Region{{Start: 0, End: 1.5}, {Start: 2, End: 3.25}, {Start: 4, End: 5}}
+
+	cp := newCheckpoint(filename, "ja", "webrtc", audio, regions)
+	if got := cp.missing(); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("missing: %v", got)
+	}
+	done := Subtitle{Region: regions[1], ID: 1, Source: "こんにちは", Confidence: 0.9, Engine: "google", Attempts: 1}
+	cp.add(done)
+	// 识别失败的区域不记录，-resume时重新处理
+	cp.add(Subtitle{Region: regions[2], ID: 2, Err: "timeout"})
+	if got := cp.missing(); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("missing after add: %v", got)
+	}
+	if err := cp.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 人声概率只影响JSON输出，不影响进度复用
+	audio.SpeechProb = true
+	loaded, err := loadCheckpoint(filename, "ja", "webrtc", audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Regions, regions) || !reflect.DeepEqual(loaded.Done, map[int]Subtitle{1: done}) {
+		t.Errorf("loaded: %+v", loaded)
+	}
+	if loaded.path != cp.path {
+		t.Errorf("path: %s, want %s", loaded.path, cp.path)
+	}
+
+	for _, c := range []struct {
+		lang, vadMode string
+		audio         voice.Options
+	}{
+		{"en", "webrtc", audio},
+		{"ja", "energy", audio},
+		{"ja", "webrtc", voice.Options{AudioStream: 0, Channel: "FC", HighPass: 80}},
+		{"ja", "webrtc", voice.Options{AudioStream: 1, Channel: "FC", HighPass: 80, Isolate: voice.IsolateCenter}},
+	} {
+		if _, err := loadCheckpoint(filename, c.lang, c.vadMode, c.audio); err == nil {
+			t.Errorf("%s %s %+v should not resume", c.lang, c.vadMode, c.audio)
+		}
+	}
+
+	cp.remove()
+	if _, err := loadCheckpoint(filename, "ja", "webrtc", audio); err == nil {
+		t.Error("removed checkpoint should not resume")
+	}
+}
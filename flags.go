@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/MeteorsLiu/goSRT/srt"
+	"github.com/MeteorsLiu/goSRT/translate"
+)
+
+// translateFlags 是主命令和 translate 子命令共用的翻译选项
+type translateFlags struct {
+	to       string
+	engine   string
+	url      string
+	key      string
+	model    string
+	batch    int
+	context  int
+	glossary string
+}
+
+// addTranslateFlags 在fs上注册翻译选项
+func addTranslateFlags(fs *flag.FlagSet) *translateFlags {
+	f := &translateFlags{}
+	fs.StringVar(&f.to, "to", "zh-CN", "Target language of translation, e.g. en or ja (翻译目标语言)")
+	fs.StringVar(&f.engine, "translate-engine", "auto", "Translate engine: "+strings.Join(translate.Engines(), ", ")+", comma separated for fallbacks, e.g. openai,google (翻译引擎，逗号分隔为后备引擎)")
+	fs.StringVar(&f.url, "translate-url", "", "Base URL of the libretranslate or openai engine, e.g. http://localhost:5000 (自建翻译服务地址)")
+	fs.StringVar(&f.key, "translate-key", "", "API key of the libretranslate or openai engine (翻译服务API Key)")
+	fs.StringVar(&f.model, "translate-model", "", "Model of the openai engine (openai兼容接口使用的模型)")
+	fs.IntVar(&f.batch, "translate-batch", 10, "Cues per translation request, 1 to translate one by one (每次翻译的字幕条数)")
+	fs.IntVar(&f.context, "translate-context", 2, "Neighboring cues sent with each batch as the context (批量翻译时附带的前后字幕条数)")
+	fs.StringVar(&f.glossary, "glossary", "", "Glossary file, TSV of \"source<TAB>target\" lines or JSON (术语表)")
+	return f
+}
+
+// config 读取术语表，返回翻译配置
+func (f *translateFlags) config() (translate.Config, error) {
+	tc := translate.Config{
+		Engine:  f.engine,
+		Target:  f.to,
+		URL:     f.url,
+		Key:     f.key,
+		Model:   f.model,
+		Batch:   f.batch,
+		Context: f.context,
+	}
+	if f.glossary != "" {
+		terms, err := translate.LoadGlossary(f.glossary)
+		if err != nil {
+			return tc, err
+		}
+		tc.Glossary = terms
+	}
+	return tc, nil
+}
+
+// layoutFlags 是主命令和 translate 子命令共用的排版和时间轴选项
+type layoutFlags struct {
+	maxChars    int
+	maxLines    int
+	maxCPS      float64
+	minDuration time.Duration
+	minGap      time.Duration
+}
+
+// addLayoutFlags 在fs上注册排版和时间轴选项，timing为最短时长和最小间隔的默认值
+func addLayoutFlags(fs *flag.FlagSet, timing srt.Timing) *layoutFlags {
+	f := &layoutFlags{}
+	fs.IntVar(&f.maxChars, "max-chars", 0, "Max width per line, a CJK character counts as 2, 0 to disable wrapping (每行最大宽度)")
+	fs.IntVar(&f.maxLines, "max-lines", 0, "Max lines per cue, 0 for no limit (每条字幕最大行数)")
+	fs.Float64Var(&f.maxCPS, "max-cps", 0, "Max characters per second, 0 for no limit (每秒最大字数)")
+	fs.DurationVar(&f.minDuration, "min-duration", timing.MinDuration, "Min duration of a cue, short cues are extended into the silence (每条字幕最短时长)")
+	fs.DurationVar(&f.minGap, "min-gap", timing.MinGap, "Min gap between two cues (字幕最小间隔)")
+	return f
+}
+
+func (f *layoutFlags) layout() srt.Layout {
+	return srt.Layout{
+		MaxChars: f.maxChars,
+		MaxLines: f.maxLines,
+		MaxCPS:   f.maxCPS,
+	}
+}
+
+func (f *layoutFlags) timing() srt.Timing {
+	return srt.Timing{
+		MinDuration: f.minDuration,
+		MinGap:      f.minGap,
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MeteorsLiu/goSRT/srt"
+)
+
+// lintReport 是 lint -json 的输出格式
+type lintReport struct {
+	File   string      `json:"file"`
+	Cues   int         `json:"cues"`
+	Issues []srt.Issue `json:"issues"`
+	Fixed  string      `json:"fixed,omitempty"`
+}
+
+// runLint 处理 lint 子命令，检查字幕文件的时间轴、编号、排版和编码问题
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON (输出JSON报告)")
+	fix := fs.Bool("fix", false, "Fix the issues and write the file again (自动修复)")
+	output := fs.String("o", "", "Output file of -fix, default xxx.fixed.srt (修复后的输出文件)")
+	maxChars := fs.Int("max-chars", 42, "Max width per line, a CJK character counts as 2, 0 to disable (每行最大宽度)")
+	maxCPS := fs.Float64("max-cps", 20, "Max characters per second, 0 to disable (每秒最大字数)")
+	minDuration := fs.Duration("min-duration", 0, "Min duration of a cue for -fix (修复时每条字幕最短时长)")
+	minGap := fs.Duration("min-gap", 0, "Min gap between two cues for -fix (修复时字幕最小间隔)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gotranscriber lint [options] file.srt")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("missing subtitle file")
+	}
+	input := fs.Arg(0)
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	opts := srt.LintOptions{MaxChars: *maxChars, MaxCPS: *maxCPS}
+	issues, cues := srt.Lint(data, opts)
+	report := lintReport{File: input, Cues: len(cues), Issues: issues}
+	if report.Issues == nil {
+		report.Issues = []srt.Issue{}
+	}
+
+	if *fix && cues != nil && len(issues) > 0 {
+		fixed := srt.Fix(cues, opts, srt.Timing{MinDuration: *minDuration, MinGap: *minGap})
+		if *output == "" {
+			*output = derivedNameOf(input, "fixed")
+		}
+		if err := writeCues(*output, fixed); err != nil {
+			return err
+		}
+		report.Fixed = *output
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		fmt.Printf("%s: %d cues, %d issues\n", input, len(cues), len(issues))
+		if report.Fixed != "" {
+			fmt.Println("Fixed to", report.Fixed)
+		}
+	}
+
+	// 有未修复的问题时返回非0，方便在脚本中使用
+	if len(issues) > 0 && report.Fixed == "" {
+		return fmt.Errorf("%d issues found", len(issues))
+	}
+	return nil
+}
@@ -4,8 +4,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/MeteorsLiu/goSRT/cache"
+	"github.com/MeteorsLiu/goSRT/mux"
+	"github.com/MeteorsLiu/goSRT/srt"
 	"github.com/MeteorsLiu/goSRT/transcribe"
+	"github.com/MeteorsLiu/goSRT/translate"
+	"github.com/MeteorsLiu/goSRT/voice"
 )
 
 var (
@@ -14,22 +21,121 @@ var (
 	filename      string
 	needTranslate bool
 	vadMode       string
+	audioStream   int
+	channel       string
+	listStreams   bool
+	dryRun        bool
+	highPass      float64
+	loudnorm      bool
+	noiseGate     float64
+	isolate       string
+	format        string
+	vttSettings   string
+	assStyles     string
+	subtitleMode  string
+	muxMode       string
+	muxOutput     string
+	burnStyle     string
+	cachePath     string
+	resume        bool
 )
 
+// subcommands 子命令，用法: gotranscriber <子命令> [选项]
+var subcommands = map[string]func(args []string) error{
+	"retime":    runRetime,
+	"lint":      runLint,
+	"translate": runTranslate,
+}
+
 func main() {
-	flag.BoolVar(&needTranslate, "translate", true, "是否自动翻译成中文")
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	flag.BoolVar(&needTranslate, "translate", true, "是否自动翻译")
+	tf := addTranslateFlags(flag.CommandLine)
 	flag.StringVar(&lang, "lang", "", "Source Video Language(源文件语言)")
 	flag.StringVar(&filename, "file", "", "Source Video(原视频文件)")
 	flag.StringVar(&vadMode, "vad", "webrtc", "VAD Mode: webrtc (default) or energy (autosub method)")
 	flag.IntVar(&numConcurrent, "concurrency", 10, "Concurrent transcribing ")
+	flag.IntVar(&audioStream, "audio-stream", -1, "Audio stream index, e.g. 1 for the second audio track (音轨序号，默认第一条音轨)")
+	flag.StringVar(&channel, "channel", "", "Only use one channel, e.g. FC for the center channel of 5.1 (只使用某个声道)")
+	flag.BoolVar(&listStreams, "list-streams", false, "List audio streams of the file and exit (列出音轨)")
+	flag.Float64Var(&highPass, "highpass", 0, "High-pass filter cutoff (Hz) before VAD, 0 to disable (VAD前高通滤波)")
+	flag.BoolVar(&loudnorm, "loudnorm", false, "EBU R128 loudness normalization before VAD (VAD前响度标准化)")
+	flag.Float64Var(&noiseGate, "gate", 0, "Noise gate threshold (dBFS, e.g. -45) before VAD, 0 to disable (VAD前噪声门)")
+	flag.StringVar(&isolate, "isolate", "", "Vocal isolation for stereo sources with BGM: center or dialogue (立体声人声分离)")
+	flag.StringVar(&format, "format", "srt", "Subtitle format: srt, vtt, ass, ttml, dfxp, sbv or json (字幕格式)")
+	flag.StringVar(&vttSettings, "vtt-settings", "", "WebVTT cue settings, e.g. \"line:85% align:center\" (WebVTT字幕位置设置)")
+	flag.StringVar(&assStyles, "ass-styles", "", "File with ASS \"Style:\" lines (or an .ass template) for the [V4+ Styles] section (ASS样式文件)")
+	flag.StringVar(&subtitleMode, "output", "translation", "Subtitle output: translation, source, stacked (bilingual) or split (two files) (原文/译文输出方式)")
+	lf := addLayoutFlags(flag.CommandLine, srt.Timing{MinDuration: time.Second, MinGap: 80 * time.Millisecond})
+	flag.StringVar(&muxMode, "mux", "", "Put the subtitle into the video: soft (mkv/mp4 track) or burn (把字幕写入视频: 软字幕或硬字幕)")
+	flag.StringVar(&muxOutput, "mux-output", "", "Output video of -mux, default xxx.subbed.<ext>, mkv if the container can't hold soft subtitles (写入字幕后的视频文件)")
+	flag.StringVar(&burnStyle, "burn-style", "", "ASS style override of -mux burn, e.g. FontName=Arial,FontSize=24 (硬字幕样式)")
+	flag.StringVar(&cachePath, "cache", cache.DefaultPath(), "Cache file of the recognition and translation results, empty to disable (识别和翻译结果缓存文件)")
+	flag.BoolVar(&resume, "resume", false, "Resume the interrupted job from xxx.checkpoint.json (从进度文件继续中断的任务)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only probe and detect voice regions, report the estimated slices and requests (只估算切片和请求数量)")
 	flag.Parse()
 
+	if listStreams && filename != "" {
+		streams, err := voice.ListAudioStreams(filename)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, stream := range streams {
+			fmt.Println(stream)
+		}
+		os.Exit(0)
+	}
+
 	if lang == "" || filename == "" {
 		fmt.Println("具体使用方式：")
 		fmt.Println("./gotranscriber -file xxx.mp4(File to be transcribed. 需要听识的视频/音频文件) -lang ja(原视频文件语言缩写)")
 		fmt.Println("选项参数：")
-		fmt.Println("  -translate  是否自动翻译成中文 (默认: true)")
+		fmt.Println("  -translate  是否自动翻译 (默认: true)")
+		fmt.Println("  -to         翻译目标语言，例如 en, ja (默认: zh-CN)")
+		fmt.Println("  -translate-engine  翻译引擎: " + strings.Join(translate.Engines(), ", ") + " (默认: auto，中国大陆且目标为中文时使用有道，否则使用Google)，逗号分隔依次作为后备引擎，例如 openai,google")
+		fmt.Println("  -translate-url  自建翻译服务地址，libretranslate必填，openai默认为 " + translate.OPENAI_URL)
+		fmt.Println("  -translate-key  翻译服务API Key")
+		fmt.Println("  -translate-model  openai兼容接口使用的模型，例如 qwen2.5:14b")
+		fmt.Println("  -translate-batch  每次翻译的字幕条数，1为逐条翻译 (默认: 10)")
+		fmt.Println("  -translate-context  批量翻译时附带的前后字幕条数，只作为上下文参考 (默认: 2)")
+		fmt.Println("  -glossary   术语表文件，TSV或JSON，固定人名等术语的译法")
 		fmt.Println("  -vad        VAD模式: webrtc (默认) 或 energy (autosub方法)")
+		fmt.Println("  -audio-stream  音轨序号，例如 1 表示第二条音轨 (默认: 第一条音轨)")
+		fmt.Println("  -channel    只使用某个声道，例如 FC 表示5.1中置声道")
+		fmt.Println("  -list-streams  列出文件的所有音轨")
+		fmt.Println("  -highpass   VAD前高通滤波截止频率(Hz)，例如 80")
+		fmt.Println("  -loudnorm   VAD前进行EBU R128响度标准化")
+		fmt.Println("  -gate       VAD前噪声门阈值(dBFS)，例如 -45")
+		fmt.Println("  -isolate    立体声人声分离: center 或 dialogue，用于缓解BGM影响")
+		fmt.Println("  -format     字幕格式: srt (默认), vtt, ass, ttml, dfxp, sbv 或 json")
+		fmt.Println("  -vtt-settings  WebVTT字幕设置，例如 \"line:85% align:center\"")
+		fmt.Println("  -ass-styles  ASS样式文件，包含 Style: 行或直接使用现有的.ass文件")
+		fmt.Println("  -output     输出方式: translation (默认，只输出译文), source (只输出原文), stacked (双语), split (原文译文分两个文件)")
+		fmt.Println("  -max-chars  每行最大宽度(中日韩文字算2)，例如 42，默认不折行")
+		fmt.Println("  -max-lines  每条字幕最大行数，例如 2，超出的行拆分为新字幕")
+		fmt.Println("  -max-cps    每秒最大字数，例如 17，超出的字幕按行拆分(单行先折成两行)，并延长到后面的静音中")
+		fmt.Println("  -min-duration  每条字幕最短时长，过短的字幕延长到后面的静音中 (默认: 1s)")
+		fmt.Println("  -min-gap    字幕之间的最小间隔 (默认: 80ms)")
+		fmt.Println("  -mux        把字幕写入视频: soft (软字幕，mkv/mp4字幕轨) 或 burn (硬字幕，重新编码视频)")
+		fmt.Println("  -mux-output  写入字幕后的视频文件 (默认: xxx.subbed.<原扩展名>，软字幕在容器不支持时为mkv)")
+		fmt.Println("  -burn-style  硬字幕样式，例如 FontName=Arial,FontSize=24,Outline=2")
+		fmt.Println("  -cache      识别和翻译结果缓存文件，重新运行时不再上传相同的切片，设为空字符串关闭 (默认: " + cache.DefaultPath() + ")")
+		fmt.Println("  -resume     从进度文件 xxx.checkpoint.json 继续中断的任务，只处理没有完成的切片")
+		fmt.Println("  -dry-run    只做人声区域识别，估算切片和请求数量，不上传")
+		fmt.Println("子命令：")
+		fmt.Println("  retime      修正已有字幕的时间轴: gotranscriber retime -shift 1.5s | -fps 23.976:25 | -sync \"A=B;C=D\" xxx.srt")
+		fmt.Println("  translate   翻译已有的SRT/WebVTT字幕，不需要重新识别: gotranscriber translate -to zh-CN [-output stacked] xxx.srt")
+		fmt.Println("  lint        检查字幕文件的时间轴、编号、排版和编码问题: gotranscriber lint [-json] [-fix] xxx.srt")
 		fmt.Println("以下是语言简写")
 		for k, v := range transcribe.GetLangCode() {
 			fmt.Println(k, "->", v)
@@ -37,6 +143,56 @@ func main() {
 		os.Exit(0)
 	}
 
-	DoVad(numConcurrent, needTranslate, lang, filename, vadMode)
+	var styles []srt.Style
+	if assStyles != "" {
+		f, err := os.Open(assStyles)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		styles, err = srt.LoadStyles(f)
+		f.Close()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	tc, err := tf.config()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var store *cache.Cache
+	if cachePath != "" && !dryRun {
+		store, err = cache.Open(cachePath)
+		if err != nil {
+			fmt.Println("cache disabled:", err)
+		} else {
+			defer store.Close()
+		}
+	}
+
+	DoVad(numConcurrent, needTranslate, lang, filename, vadMode, voice.Options{
+		AudioStream: audioStream,
+		Channel:     channel,
+		HighPass:    highPass,
+		Loudnorm:    loudnorm,
+		NoiseGate:   noiseGate,
+		Isolate:     voice.IsolateMode(isolate),
+	}, tc, store, resume, dryRun, Output{
+		Format:      srt.Format(format),
+		Mode:        SubtitleMode(subtitleMode),
+		VTTSettings: vttSettings,
+		ASSStyles:   styles,
+		Layout:      lf.layout(),
+		Timing:      lf.timing(),
+		Mux: mux.Options{
+			Mode:   mux.Mode(muxMode),
+			Output: muxOutput,
+			Style:  burnStyle,
+		},
+	})
 
 }
@@ -0,0 +1,222 @@
+// Package mux puts subtitle files into a video with ffmpeg
+package mux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Mode is how the subtitles are put into the video
+type Mode string
+
+const (
+	None Mode = ""
+	Soft Mode = "soft" // add the subtitles as soft tracks, the streams are copied
+	Burn Mode = "burn" // render the subtitle into the video frames, the video is re-encoded
+)
+
+// Track is a subtitle file to be muxed
+type Track struct {
+	Path     string
+	Language string // BCP 47 like ja or zh-CN, converted to ISO 639-2 for the container
+	Title    string
+}
+
+// Options controls Mux
+type Options struct {
+	Mode Mode
+	// Output is the output video, it must differ from the input
+	Output string
+	// Style overrides the style when burning, in the ASS "Key=Value,Key=Value" form,
+	// e.g. FontName=Arial,FontSize=24,Outline=2
+	Style string
+}
+
+// iso639 maps the primary language subtag to the ISO 639-2/B code used by mkv and mp4
+var iso639 = map[string]string{
+	"af": "afr", "ar": "ara", "az": "aze", "be": "bel", "bg": "bul",
+	"bn": "ben", "bs": "bos", "ca": "cat", "ceb": "ceb", "cs": "cze",
+	"cy": "wel", "da": "dan", "de": "ger", "el": "gre", "en": "eng",
+	"eo": "epo", "es": "spa", "et": "est", "eu": "baq", "fa": "per",
+	"fi": "fin", "fil": "fil", "fr": "fre", "ga": "gle", "gl": "glg",
+	"gu": "guj", "he": "heb", "hi": "hin", "hr": "hrv", "hu": "hun",
+	"hy": "arm", "id": "ind", "is": "ice", "it": "ita", "iw": "heb",
+	"ja": "jpn", "jv": "jav", "ka": "geo", "kk": "kaz", "km": "khm",
+	"kn": "kan", "ko": "kor", "la": "lat", "lo": "lao", "lt": "lit",
+	"lv": "lav", "mk": "mac", "ml": "mal", "mn": "mon", "mr": "mar",
+	"ms": "may", "my": "bur", "ne": "nep", "nl": "dut", "no": "nor",
+	"pa": "pan", "pl": "pol", "pt": "por", "ro": "rum", "ru": "rus",
+	"si": "sin", "sk": "slo", "sl": "slv", "sq": "alb", "sr": "srp",
+	"su": "sun", "sv": "swe", "sw": "swa", "ta": "tam", "te": "tel",
+	"th": "tha", "tl": "tgl", "tr": "tur", "uk": "ukr", "ur": "urd",
+	"uz": "uzb", "vi": "vie", "yue": "chi", "zh": "chi", "zu": "zul",
+}
+
+// LanguageCode converts a BCP 47 tag like zh-CN to the ISO 639-2 code like chi,
+// und is returned for unknown languages.
+func LanguageCode(lang string) string {
+	primary, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	primary = strings.ToLower(primary)
+	if code, ok := iso639[primary]; ok {
+		return code
+	}
+	if len(primary) == 3 {
+		return primary
+	}
+	return "und"
+}
+
+// subtitleCodec returns the subtitle codec supported by the output container
+func subtitleCodec(output, subtitle string) (string, error) {
+	sub := strings.ToLower(filepath.Ext(subtitle))
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".mp4", ".m4v", ".mov":
+		return "mov_text", nil
+	case ".webm":
+		return "webvtt", nil
+	case ".mkv", ".mka":
+		switch sub {
+		case ".srt":
+			return "srt", nil
+		case ".ass", ".ssa":
+			return "ass", nil
+		case ".vtt":
+			return "webvtt", nil
+		}
+		return "", fmt.Errorf("%s can't be muxed into mkv", subtitle)
+	}
+	return "", fmt.Errorf("soft subtitles are not supported by %s, use mkv or mp4", output)
+}
+
+// Ext returns the extension of the default output video: burning keeps the container
+// of the input, soft subtitles fall back to mkv when the input container can't hold them.
+func Ext(video string, mode Mode) string {
+	ext := filepath.Ext(video)
+	if mode != Soft {
+		return ext
+	}
+	switch strings.ToLower(ext) {
+	case ".mp4", ".m4v", ".mov", ".mkv", ".webm":
+		return ext
+	}
+	return ".mkv"
+}
+
+// countStreams returns the number of streams in the file,
+// the muxed subtitles follow them in the output.
+func countStreams(filename string) (int, error) {
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return 0, errors.New("please install ffmpeg (ffprobe)")
+	}
+	output, err := exec.Command(ffprobe, "-v", "quiet", "-print_format", "json", "-show_streams", filename).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	var probe struct {
+		Streams []struct {
+			Index int `json:"index"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return 0, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return len(probe.Streams), nil
+}
+
+// softArgs builds the ffmpeg arguments which copy all the streams of the video
+// and append the subtitles as new tracks, the first one is the default.
+func softArgs(video string, streams int, tracks []Track, output string) ([]string, error) {
+	args := []string{"-y", "-i", video}
+	for _, track := range tracks {
+		args = append(args, "-i", track.Path)
+	}
+	args = append(args, "-map", "0")
+	for i := range tracks {
+		args = append(args, "-map", fmt.Sprintf("%d:0", i+1))
+	}
+	args = append(args, "-c", "copy")
+	for i, track := range tracks {
+		codec, err := subtitleCodec(output, track.Path)
+		if err != nil {
+			return nil, err
+		}
+		// absolute output stream index
+		index := streams + i
+		args = append(args,
+			fmt.Sprintf("-c:%d", index), codec,
+			fmt.Sprintf("-metadata:s:%d", index), "language="+LanguageCode(track.Language),
+		)
+		if track.Title != "" {
+			args = append(args, fmt.Sprintf("-metadata:s:%d", index), "title="+track.Title)
+		}
+		disposition := "0"
+		if i == 0 {
+			disposition = "default"
+		}
+		args = append(args, fmt.Sprintf("-disposition:%d", index), disposition)
+	}
+	return append(args, output), nil
+}
+
+// escapeFilterValue escapes the value of a filter option, which is parsed twice:
+// once as the option value and once as the filtergraph.
+func escapeFilterValue(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(s)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(s)
+}
+
+// burnArgs builds the ffmpeg arguments which render the subtitle into the video
+func burnArgs(video string, track Track, style, output string) []string {
+	filter := "subtitles=filename=" + escapeFilterValue(track.Path)
+	if style != "" {
+		filter += ":force_style=" + escapeFilterValue(style)
+	}
+	return []string{"-y", "-i", video, "-vf", filter, "-c:a", "copy", output}
+}
+
+// Run puts the subtitle tracks into the video with ffmpeg,
+// only one track can be burned.
+func Run(video string, tracks []Track, opts Options) error {
+	if len(tracks) == 0 {
+		return errors.New("no subtitle to mux")
+	}
+	if opts.Output == "" || filepath.Clean(opts.Output) == filepath.Clean(video) {
+		return errors.New("the output of mux must differ from the input video")
+	}
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return errors.New("please install ffmpeg")
+	}
+
+	var args []string
+	switch opts.Mode {
+	case Soft:
+		streams, err := countStreams(video)
+		if err != nil {
+			return err
+		}
+		args, err = softArgs(video, streams, tracks, opts.Output)
+		if err != nil {
+			return err
+		}
+	case Burn:
+		if len(tracks) > 1 {
+			return errors.New("only one subtitle can be burned, use the stacked output for bilingual subtitles")
+		}
+		args = burnArgs(video, tracks[0], opts.Style, opts.Output)
+	default:
+		return fmt.Errorf("unknown mux mode: %s", opts.Mode)
+	}
+
+	fmt.Println("Mux", opts.Output)
+	ret, err := exec.Command(ffmpeg, args...).CombinedOutput()
+	if err != nil {
+		return errors.New(string(ret))
+	}
+	return nil
+}
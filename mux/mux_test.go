@@ -0,0 +1,51 @@
+package mux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMuxArgs(t *testing.T) {
+	for lang, code := range map[string]string{"ja": "jpn", "zh-CN": "chi", "en_US": "eng", "fil": "fil", "xx": "und", "": "und"} {
+		if got := LanguageCode(lang); got != code {
+			t.Errorf("LanguageCode(%q) = %s, want %s", lang, got, code)
+		}
+	}
+
+	args, err := softArgs("a.mkv", 3, []Track{{Path: "a.zh-CN.ass", Language: "zh-CN"}, {Path: "a.ja.ass", Language: "ja"}}, "a.subbed.mkv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-y -i a.mkv -i a.zh-CN.ass -i a.ja.ass -map 0 -map 1:0 -map 2:0 -c copy " +
+		"-c:3 ass -metadata:s:3 language=chi -disposition:3 default " +
+		"-c:4 ass -metadata:s:4 language=jpn -disposition:4 0 a.subbed.mkv"
+	if got := strings.Join(args, " "); got != want {
+		t.Errorf("softArgs:\n%s\nwant\n%s", got, want)
+	}
+	if _, err := softArgs("a.avi", 2, []Track{{Path: "a.srt"}}, "a.subbed.avi"); err == nil {
+		t.Error("avi should be rejected")
+	}
+
+	args = burnArgs("a.mp4", Track{Path: `C:\sub's.srt`}, "FontName=Arial,FontSize=24", "b.mp4")
+	if want := `subtitles=filename=C\\:\\\\sub\\\'s.srt:force_style=FontName=Arial\,FontSize=24`; args[4] != want {
+		t.Errorf("burnArgs filter %s, want %s", args[4], want)
+	}
+}
+
+func TestExt(t *testing.T) {
+	for _, c := range []struct {
+		video string
+		mode  Mode
+		want  string
+	}{
+		{"a.mp4", Soft, ".mp4"},
+		{"a.MKV", Soft, ".MKV"},
+		{"a.avi", Soft, ".mkv"},
+		{"a.flv", Soft, ".mkv"},
+		{"a.avi", Burn, ".avi"},
+	} {
+		if got := Ext(c.video, c.mode); got != c.want {
+			t.Errorf("Ext(%s, %s) = %s, want %s", c.video, c.mode, got, c.want)
+		}
+	}
+}
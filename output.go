@@ -0,0 +1,363 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MeteorsLiu/goSRT/mux"
+	"github.com/MeteorsLiu/goSRT/srt"
+)
+
+// SubtitleMode 定义原文和译文的输出方式
+type SubtitleMode string
+
+const (
+	ModeTranslation SubtitleMode = "translation" // 只输出译文，没有译文的字幕保留原文(默认)
+	ModeSource      SubtitleMode = "source"      // 只输出原文
+	ModeStacked     SubtitleMode = "stacked"     // 双语，每条字幕原文在上译文在下
+	ModeSplit       SubtitleMode = "split"       // 原文和译文分别输出两个文件
+)
+
+// FormatJSON 输出带有每条字幕元数据的JSON，而不是字幕文件
+const FormatJSON srt.Format = "json"
+
+// transcriptJSON 是JSON输出的格式
+type transcriptJSON struct {
+	File     string    `json:"file"`
+	Language string    `json:"language"`
+	Target   string    `json:"target,omitempty"`
+	Cues     []cueJSON `json:"cues"`
+	// Untranslated 是翻译失败的字幕序号
+	Untranslated []int `json:"untranslated,omitempty"`
+}
+
+type cueJSON struct {
+	Index       int     `json:"index"`
+	Region      region  `json:"region"`
+	Text        string  `json:"text"`
+	Translation string  `json:"translation,omitempty"`
+	Confidence  float64 `json:"confidence"`
+	SpeechProb  float64 `json:"speech_probability"`
+	Engine      string  `json:"engine"`
+	Attempts    int     `json:"attempts"`
+	Error       string  `json:"error,omitempty"`
+
+	TranslationEngine string `json:"translation_engine,omitempty"`
+	TranslationError  string `json:"translation_error,omitempty"`
+}
+
+type region struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Output 控制字幕文件的输出
+type Output struct {
+	Format      srt.Format   // 字幕格式: srt, vtt, ass, ttml, dfxp, sbv 或 json
+	Mode        SubtitleMode // 原文和译文的输出方式
+	VTTSettings string       // WebVTT默认cue settings，例如 "line:85% align:center"
+	ASSStyles   []srt.Style  // ASS [V4+ Styles]，为空使用默认样式
+	Layout      srt.Layout   // 折行和阅读速度限制
+	Timing      srt.Timing   // 修正重叠、最短时长和最小间隔
+	Mux         mux.Options  // 字幕写入视频: 软字幕或硬字幕，Output为空时为 xxx.subbed.<原扩展名>
+
+	// 写入字幕注释的引擎，为空时不写
+	Engine            string // 识别引擎
+	TranslationEngine string // 翻译引擎，多个引擎时为后备链，例如 "openai -> google"
+}
+
+// writeJSON 输出JSON，包括识别失败的区域
+func (o Output) writeJSON(filename, vadMode, lang, target string, subs []Subtitle) error {
+	transcript := transcriptJSON{
+		File:     filename,
+		Language: lang,
+		Cues:     make([]cueJSON, 0, len(subs)),
+	}
+	if o.Mode != ModeSource {
+		transcript.Target = target
+	}
+	for _, sub := range subs {
+		transcript.Cues = append(transcript.Cues, cueJSON{
+			Index:       sub.ID + 1,
+			Region:      region{Start: sub.Start, End: sub.End},
+			Text:        sub.Source,
+			Translation: sub.Translation,
+			Confidence:  sub.Confidence,
+			SpeechProb:  sub.SpeechProb,
+			Engine:      sub.Engine,
+			Attempts:    sub.Attempts,
+			Error:       sub.Err,
+
+			TranslationEngine: sub.TranslationEngine,
+			TranslationError:  sub.TranslationErr,
+		})
+		if sub.TranslationErr != "" {
+			transcript.Untranslated = append(transcript.Untranslated, sub.ID+1)
+		}
+	}
+	data, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := subtitleNameOf(filename, vadMode, FormatJSON.Ext())
+	log.Println("Write transcript", name)
+	return os.WriteFile(name, data, 0755)
+}
+
+// writerOf 创建对应格式的字幕Writer，langs为字幕包含的语言，第一个为主语言
+func (o Output) writerOf(langs ...string) (srt.Writer, error) {
+	switch o.Mode {
+	case ModeTranslation, ModeSource, ModeStacked, ModeSplit:
+	default:
+		return nil, fmt.Errorf("unknown subtitle mode: %s", o.Mode)
+	}
+	switch o.Mux.Mode {
+	case mux.None:
+	case mux.Soft, mux.Burn:
+		switch o.Format {
+		case srt.FormatSRT, srt.FormatVTT, srt.FormatASS:
+		default:
+			return nil, fmt.Errorf("%s can't be muxed, use srt, vtt or ass", o.Format)
+		}
+		// split输出两个字幕文件，只有一个能烧录
+		if o.Mux.Mode == mux.Burn && o.Mode == ModeSplit {
+			return nil, errors.New("split writes two subtitles but only one can be burned, use -output stacked instead")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mux mode: %s", o.Mux.Mode)
+	}
+	if o.Format == FormatJSON {
+		// JSON不是字幕格式，用SRT校验参数
+		return srt.New(), nil
+	}
+	w, err := srt.NewWriter(o.Format)
+	if err != nil {
+		return nil, err
+	}
+	if vtt, ok := w.(*srt.VTT); ok {
+		vtt.Settings = o.VTTSettings
+	}
+	if ass, ok := w.(*srt.ASS); ok && len(o.ASSStyles) > 0 {
+		ass.Styles = o.ASSStyles
+	}
+	if ttml, ok := w.(*srt.TTML); ok && len(langs) > 0 {
+		ttml.Lang = langs[0]
+	}
+	return w, nil
+}
+
+// noteEngines 在支持注释的格式中记录识别引擎、翻译引擎和字幕语言，translated表示字幕包含译文
+func (o Output) noteEngines(w srt.Writer, translated bool, langs ...string) {
+	n, ok := w.(srt.Noter)
+	if !ok {
+		return
+	}
+	var lines []string
+	if o.Engine != "" {
+		lines = append(lines, "engine: "+o.Engine)
+	}
+	if translated && o.TranslationEngine != "" {
+		lines = append(lines, "translation engine: "+o.TranslationEngine)
+	}
+	lines = append(lines, "language: "+strings.Join(langs, ", "))
+	n.AddNote(strings.Join(lines, "\n"))
+}
+
+// noteUntranslated 在支持注释的格式中标记翻译失败的字幕，方便发现只翻译了一部分的文件
+func noteUntranslated(w srt.Writer, subs []Subtitle) {
+	n, ok := w.(srt.Noter)
+	if !ok {
+		return
+	}
+	var starts []string
+	for _, sub := range subs {
+		if sub.TranslationErr != "" {
+			starts = append(starts, timestampOf(sub.Start))
+		}
+	}
+	if len(starts) > 0 {
+		n.AddNote(fmt.Sprintf("untranslated: %d of %d cues, the source text is kept (at %s)", len(starts), len(subs), strings.Join(starts, ", ")))
+	}
+}
+
+// timestampOf 把秒转换为 00:02:17.990 格式，用于在日志和注释中定位字幕
+func timestampOf(sec float64) string {
+	ms := srt.FromSeconds(sec).Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// cueOf 把字幕文本转换为cue
+func cueOf(sub Subtitle, text string) srt.Cue {
+	return srt.Cue{
+		Start: srt.FromSeconds(sub.Start),
+		End:   srt.FromSeconds(sub.End),
+		Lines: strings.Split(text, "\n"),
+	}
+}
+
+// translated 返回译文，没有译文时返回原文
+func (s Subtitle) translated() string {
+	if s.Translation == "" {
+		return s.Source
+	}
+	return s.Translation
+}
+
+// appendAll 排版后写入字幕，text决定每条字幕使用原文还是译文
+func (o Output) appendAll(w srt.Writer, subs []Subtitle, text func(Subtitle) string) {
+	cues := make([]srt.Cue, 0, len(subs))
+	for _, sub := range subs {
+		cues = append(cues, cueOf(sub, text(sub)))
+	}
+	for _, cue := range o.Timing.Normalize(o.Layout.Apply(cues)) {
+		w.AppendCue(cue)
+	}
+}
+
+// wrap 按每行最大宽度折行，双语字幕只折行不拆分，避免原文和译文错开
+func (o Output) wrap(text string) string {
+	if o.Layout.MaxChars <= 0 {
+		return text
+	}
+	return strings.Join(o.Layout.Wrap(text), "\n")
+}
+
+// appendStacked 写入双语字幕，ASS使用不同样式分别渲染原文和译文
+func (o Output) appendStacked(w srt.Writer, subs []Subtitle) {
+	cues := make([]srt.Cue, 0, len(subs))
+	for i, sub := range subs {
+		cue := cueOf(sub, sub.Source)
+		cue.Index = i
+		cues = append(cues, cue)
+	}
+	// subs已经按时间排序，Normalize不会改变顺序，但会把同时开始的字幕合并为一条，
+	// 用Index找回每条cue对应的字幕
+	cues = o.Timing.Normalize(cues)
+
+	ass, isASS := w.(*srt.ASS)
+	for k, cue := range cues {
+		next := len(subs)
+		if k+1 < len(cues) {
+			next = cues[k+1].Index
+		}
+		var sources, translations []string
+		for _, sub := range subs[cue.Index:next] {
+			sources = append(sources, sub.Source)
+			if sub.Translation != "" && sub.Translation != sub.Source {
+				translations = append(translations, sub.Translation)
+			}
+		}
+		source := o.wrap(strings.Join(sources, "\n"))
+		if len(translations) == 0 {
+			cue.Lines = strings.Split(source, "\n")
+			w.AppendCue(cue)
+			continue
+		}
+		translation := o.wrap(strings.Join(translations, "\n"))
+		if isASS && len(ass.Styles) > 1 {
+			ass.AppendStyled(cue.Start, cue.End, ass.Styles[0].Name, source)
+			ass.AppendStyled(cue.Start, cue.End, ass.Styles[1].Name, translation)
+			continue
+		}
+		cue.Lines = strings.Split(source+"\n"+translation, "\n")
+		w.AppendCue(cue)
+	}
+}
+
+// saveSubtitle 写入字幕文件，已存在时不覆盖，返回写入的文件名
+func (o Output) saveSubtitle(filename, vadMode, ext string, w srt.Writer) (string, error) {
+	name := subtitleNameOf(filename, vadMode, ext)
+	log.Println("Write subtitle", name)
+	return name, os.WriteFile(name, []byte(w.String()), 0755)
+}
+
+// mux 把生成的字幕写入视频，第一条字幕轨为默认字幕
+func (o Output) mux(filename string, tracks []mux.Track) error {
+	if o.Mux.Mode == mux.None {
+		return nil
+	}
+	opts := o.Mux
+	if opts.Output == "" {
+		opts.Output = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".subbed" + mux.Ext(filename, opts.Mode)
+	}
+	return mux.Run(filename, tracks, opts)
+}
+
+// write 按输出方式生成字幕文件，lang为原文语言，target为译文语言
+func (o Output) write(filename, vadMode, lang, target string, subs []Subtitle) error {
+	if o.Format == FormatJSON {
+		return o.writeJSON(filename, vadMode, lang, target, subs)
+	}
+	// 字幕文件不包含识别失败的区域
+	recognized := subs[:0:0]
+	for _, sub := range subs {
+		if sub.Err == "" {
+			recognized = append(recognized, sub)
+		}
+	}
+	subs = recognized
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].Start < subs[j].Start
+	})
+
+	langs := []string{target}
+	switch o.Mode {
+	case ModeSource, ModeSplit:
+		langs = []string{lang}
+	case ModeStacked:
+		langs = []string{lang, target}
+	}
+	w, err := o.writerOf(langs...)
+	if err != nil {
+		return err
+	}
+	ext := o.Format.Ext()
+	// tracks 是split模式先写入的原文，trackLang 是最后写入的字幕的语言
+	var tracks []mux.Track
+	trackLang := langs[0]
+
+	source := func(s Subtitle) string { return s.Source }
+	switch o.Mode {
+	case ModeSource:
+		o.noteEngines(w, false, langs...)
+		o.appendAll(w, subs, source)
+	case ModeStacked:
+		o.noteEngines(w, true, langs...)
+		noteUntranslated(w, subs)
+		o.appendStacked(w, subs)
+	case ModeSplit:
+		o.noteEngines(w, false, lang)
+		o.appendAll(w, subs, source)
+		name, err := o.saveSubtitle(filename, vadMode, "."+lang+ext, w)
+		if err != nil {
+			return err
+		}
+		// 软字幕时译文为默认字幕轨
+		tracks = append(tracks, mux.Track{Path: name, Language: lang})
+		w, err = o.writerOf(target)
+		if err != nil {
+			return err
+		}
+		o.noteEngines(w, true, target)
+		noteUntranslated(w, subs)
+		o.appendAll(w, subs, Subtitle.translated)
+		ext = "." + target + ext
+		trackLang = target
+	default:
+		o.noteEngines(w, true, langs...)
+		noteUntranslated(w, subs)
+		o.appendAll(w, subs, Subtitle.translated)
+	}
+	name, err := o.saveSubtitle(filename, vadMode, ext, w)
+	if err != nil {
+		return err
+	}
+	tracks = append([]mux.Track{{Path: name, Language: trackLang}}, tracks...)
+	return o.mux(filename, tracks)
+}
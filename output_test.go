@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MeteorsLiu/goSRT/mux"
+	"github.com/MeteorsLiu/goSRT/srt"
+	"github.com/MeteorsLiu/goSRT/voice"
+)
+
+// testSubtitles 包含翻译失败、识别失败和同时开始的字幕
+func testSubtitles() []Subtitle {
+	return []Subtitle{
+		{Region: voice.Region{Start: 1, End: 2}, ID: 0, Source: "こんにちは", Translation: "你好", Engine: "google", Confidence: 0.9, SpeechProb: 0.8, Attempts: 1, TranslationEngine: "google"},
+		{Region: voice.Region{Start: 3, End: 4}, ID: 1, Source: "さようなら", TranslationEngine: "google", TranslationErr: "fail"},
+		{Region: voice.Region{Start: 5, End: 6}, ID: 2, Err: "timeout", Attempts: 3},
+		{Region: voice.Region{Start: 7, End: 8.5}, ID: 3, Source: "同時", Translation: "同时"},
+		{Region: voice.Region{Start: 7, End: 8}, ID: 4, Source: "開始", Translation: "开始"},
+	}
+}
+
+// writeTest 在临时目录中输出字幕，返回文件名到内容的映射
+func writeTest(t *testing.T, o Output) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := o.write(filepath.Join(dir, "video.mp4"), "webrtc", "ja", "zh-CN", testSubtitles()); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
+
+func TestOutputModes(t *testing.T) {
+	for _, c := range []struct {
+		mode    SubtitleMode
+		want    []string
+		notWant []string
+	}{
+		{ModeTranslation, []string{"你好", "さようなら"}, []string{"こんにちは", "timeout"}},
+		{ModeSource, []string{"こんにちは", "さようなら", "同時"}, []string{"你好", "同时"}},
+		{ModeStacked, []string{"こんにちは\n你好\n", "さようなら\n\n"}, []string{"timeout"}},
+	} {
+		files := writeTest(t, Output{Format: srt.FormatSRT, Mode: c.mode})
+		text, ok := files["video.srt"]
+		if len(files) != 1 || !ok {
+			t.Errorf("%s: files %v", c.mode, files)
+			continue
+		}
+		for _, s := range c.want {
+			if !strings.Contains(text, s) {
+				t.Errorf("%s: %q not found in\n%s", c.mode, s, text)
+			}
+		}
+		for _, s := range c.notWant {
+			if strings.Contains(text, s) {
+				t.Errorf("%s: unexpected %q in\n%s", c.mode, s, text)
+			}
+		}
+	}
+}
+
+func TestOutputSplit(t *testing.T) {
+	files := writeTest(t, Output{Format: srt.FormatVTT, Mode: ModeSplit, Engine: "google-cn", TranslationEngine: "youdao -> google"})
+	source, translation := files["video.ja.vtt"], files["video.zh-CN.vtt"]
+	if len(files) != 2 || source == "" || translation == "" {
+		t.Fatalf("files: %v", files)
+	}
+	if !strings.Contains(source, "language: ja\n") || !strings.Contains(source, "こんにちは") || strings.Contains(source, "你好") {
+		t.Errorf("source:\n%s", source)
+	}
+	if !strings.Contains(translation, "language: zh-CN\n") || !strings.Contains(translation, "你好") || strings.Contains(translation, "こんにちは") {
+		t.Errorf("translation:\n%s", translation)
+	}
+	// 只有译文文件记录翻译引擎
+	if !strings.Contains(source, "engine: google-cn\nlanguage: ja\n") || !strings.Contains(translation, "engine: google-cn\ntranslation engine: youdao -> google\nlanguage: zh-CN\n") {
+		t.Errorf("engine notes:\n%s\n%s", source, translation)
+	}
+	// 只有译文文件标记翻译失败的字幕
+	if strings.Contains(source, "untranslated") || !strings.Contains(translation, "untranslated: 1 of 4 cues, the source text is kept (at 00:00:03.000)") {
+		t.Errorf("untranslated notes:\n%s\n%s", source, translation)
+	}
+}
+
+func TestOutputStackedPairing(t *testing.T) {
+	// 同时开始的两条字幕被合并，原文和译文仍然各自成对
+	text := writeTest(t, Output{Format: srt.FormatSRT, Mode: ModeStacked})["video.srt"]
+	if want := "00:00:07,000 --> 00:00:08,500\n同時\n開始\n同时\n开始\n"; !strings.Contains(text, want) {
+		t.Errorf("merged cue %q not found in\n%s", want, text)
+	}
+
+	text = writeTest(t, Output{Format: srt.FormatASS, Mode: ModeStacked})["video.ass"]
+	for _, want := range []string{
+		"0:00:01.00,0:00:02.00,Default,,0,0,0,,こんにちは\n",
+		"0:00:01.00,0:00:02.00,Translation,,0,0,0,,你好\n",
+		"0:00:03.00,0:00:04.00,Default,,0,0,0,,さようなら\n",
+		"0:00:07.00,0:00:08.50,Default,,0,0,0,,同時\\N開始\n",
+		"0:00:07.00,0:00:08.50,Translation,,0,0,0,,同时\\N开始\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q not found in\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Translation,,0,0,0,,さようなら") {
+		t.Errorf("untranslated cue is duplicated:\n%s", text)
+	}
+}
+
+func TestOutputJSON(t *testing.T) {
+	files := writeTest(t, Output{Format: FormatJSON, Mode: ModeTranslation})
+	data, ok := files["video.json"]
+	if len(files) != 1 || !ok {
+		t.Fatalf("files: %v", files)
+	}
+
+	var transcript map[string]any
+	if err := json.Unmarshal([]byte(data), &transcript); err != nil {
+		t.Fatal(err)
+	}
+	if transcript["language"] != "ja" || transcript["target"] != "zh-CN" || !strings.HasSuffix(transcript["file"].(string), "video.mp4") {
+		t.Errorf("header: %v", transcript)
+	}
+	if untranslated, _ := json.Marshal(transcript["untranslated"]); string(untranslated) != "[2]" {
+		t.Errorf("untranslated: %s", untranslated)
+	}
+	// JSON包括识别失败的区域，保持原有顺序和序号
+	cues := transcript["cues"].([]any)
+	if len(cues) != 5 {
+		t.Fatalf("cues: %v", cues)
+	}
+	first := cues[0].(map[string]any)
+	for key, want := range map[string]any{
+		"index":              1.0,
+		"region":             map[string]any{"start": 1.0, "end": 2.0},
+		"text":               "こんにちは",
+		"translation":        "你好",
+		"confidence":         0.9,
+		"speech_probability": 0.8,
+		"engine":             "google",
+		"attempts":           1.0,
+		"translation_engine": "google",
+	} {
+		if got, _ := json.Marshal(first[key]); string(got) != mustJSON(t, want) {
+			t.Errorf("%s = %s, want %s", key, got, mustJSON(t, want))
+		}
+	}
+	if _, ok := first["error"]; ok {
+		t.Errorf("error should be omitted: %v", first)
+	}
+	if failed := cues[2].(map[string]any); failed["error"] != "timeout" || failed["index"] != 3.0 {
+		t.Errorf("failed cue: %v", failed)
+	}
+	if untranslated := cues[1].(map[string]any); untranslated["translation_error"] != "fail" || untranslated["translation"] != nil {
+		t.Errorf("untranslated cue: %v", untranslated)
+	}
+
+	// 只输出原文时没有目标语言
+	files = writeTest(t, Output{Format: FormatJSON, Mode: ModeSource})
+	if strings.Contains(files["video.json"], `"target"`) {
+		t.Errorf("source only:\n%s", files["video.json"])
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriterOfMux(t *testing.T) {
+	for _, c := range []struct {
+		o  Output
+		ok bool
+	}{
+		{Output{Format: srt.FormatSRT, Mode: ModeSplit, Mux: mux.Options{Mode: mux.Soft}}, true},
+		{Output{Format: srt.FormatASS, Mode: ModeStacked, Mux: mux.Options{Mode: mux.Burn}}, true},
+		{Output{Format: srt.FormatSRT, Mode: ModeSplit, Mux: mux.Options{Mode: mux.Burn}}, false},
+		{Output{Format: srt.FormatSBV, Mode: ModeTranslation, Mux: mux.Options{Mode: mux.Soft}}, false},
+		{Output{Format: srt.FormatSRT, Mode: ModeTranslation, Mux: mux.Options{Mode: "hard"}}, false},
+	} {
+		if _, err := c.o.writerOf("ja"); (err == nil) != c.ok {
+			t.Errorf("%s %s %s: %v", c.o.Format, c.o.Mode, c.o.Mux.Mode, err)
+		}
+	}
+}
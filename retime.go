@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeteorsLiu/goSRT/srt"
+)
+
+// readCues 读取已有的SRT或WebVTT字幕文件
+func readCues(path string) ([]srt.Cue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), srt.FormatVTT.Ext()) {
+		return srt.ParseVTT(f)
+	}
+	return srt.Parse(f)
+}
+
+// writeCues 按扩展名对应的格式写入字幕文件
+func writeCues(path string, cues []srt.Cue) error {
+	w, err := srt.NewWriter(srt.Format(strings.TrimPrefix(filepath.Ext(path), ".")))
+	if err != nil {
+		return err
+	}
+	for _, cue := range cues {
+		w.AppendCue(cue)
+	}
+	return os.WriteFile(path, []byte(w.String()), 0755)
+}
+
+// derivedNameOf 生成 xxx.suffix.srt 形式的输出文件名
+func derivedNameOf(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + suffix + ext
+}
+
+// runRetime 处理 retime 子命令，不需要重新识别即可修正字幕时间轴
+func runRetime(args []string) error {
+	fs := flag.NewFlagSet("retime", flag.ExitOnError)
+	shift := fs.Duration("shift", 0, "Shift all cues, e.g. 1.5s or -800ms (整体平移)")
+	scale := fs.Float64("scale", 0, "Multiply all timestamps by the factor (按比例缩放)")
+	fps := fs.String("fps", "", "Framerate conversion, e.g. 23.976:25 (帧率转换)")
+	sync := fs.String("sync", "", "Two sync points, e.g. 00:01:00,000=00:01:02,000;01:30:00,000=01:30:05,000 (两点同步)")
+	output := fs.String("o", "", "Output file, default xxx.retimed.srt (输出文件)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gotranscriber retime [options] file.srt")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("missing subtitle file")
+	}
+	input := fs.Arg(0)
+
+	cues, err := readCues(input)
+	if err != nil {
+		return err
+	}
+
+	if *sync != "" {
+		if *shift != 0 || *scale != 0 || *fps != "" {
+			return errors.New("-sync can't be used together with -shift, -scale or -fps")
+		}
+		points := strings.Split(*sync, ";")
+		if len(points) != 2 {
+			return errors.New("-sync needs exactly two points separated by ;")
+		}
+		a, err := srt.ParseSyncPoint(points[0])
+		if err != nil {
+			return err
+		}
+		b, err := srt.ParseSyncPoint(points[1])
+		if err != nil {
+			return err
+		}
+		if cues, err = srt.Sync(cues, a, b); err != nil {
+			return err
+		}
+	} else {
+		factor := *scale
+		if *fps != "" {
+			if factor != 0 {
+				return errors.New("-scale can't be used together with -fps")
+			}
+			if factor, err = srt.ParseFramerate(*fps); err != nil {
+				return err
+			}
+		}
+		if factor == 0 && *shift == 0 {
+			fs.Usage()
+			return errors.New("nothing to do, use -shift, -scale, -fps or -sync")
+		}
+		if factor != 0 {
+			cues = srt.Scale(cues, factor)
+		}
+		if *shift != 0 {
+			cues = srt.Shift(cues, *shift)
+		}
+	}
+
+	if *output == "" {
+		*output = derivedNameOf(input, "retimed")
+	}
+	if err := writeCues(*output, cues); err != nil {
+		return err
+	}
+	fmt.Printf("Retimed %d cues to %s\n", len(cues), *output)
+	return nil
+}
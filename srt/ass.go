@@ -0,0 +1,264 @@
+package srt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	assStyleFormat = "Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, " +
+		"Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, " +
+		"Alignment, MarginL, MarginR, MarginV, Encoding"
+	assEventFormat = "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text"
+	assStyleFields = 23
+)
+
+var errStyle = errors.New("malformed style")
+
+// Style is one line of the [V4+ Styles] section,
+// colours are in the ASS form &HAABBGGRR.
+type Style struct {
+	Name            string
+	Fontname        string
+	Fontsize        float64
+	PrimaryColour   string
+	SecondaryColour string
+	OutlineColour   string
+	BackColour      string
+	Bold            bool
+	Italic          bool
+	Underline       bool
+	StrikeOut       bool
+	ScaleX          float64
+	ScaleY          float64
+	Spacing         float64
+	Angle           float64
+	BorderStyle     int
+	Outline         float64
+	Shadow          float64
+	Alignment       int // numpad layout, 2 is bottom center, 8 is top center
+	MarginL         int
+	MarginR         int
+	MarginV         int
+	Encoding        int
+}
+
+// DefaultStyles returns the default styles for a 1920x1080 script,
+// Default for the original text and Translation for the translated text.
+func DefaultStyles() []Style {
+	return []Style{
+		{
+			Name: "Default", Fontname: "Arial", Fontsize: 64,
+			PrimaryColour: "&H00FFFFFF", SecondaryColour: "&H000000FF", OutlineColour: "&H00000000", BackColour: "&H80000000",
+			ScaleX: 100, ScaleY: 100, BorderStyle: 1, Outline: 3, Shadow: 1,
+			Alignment: 2, MarginL: 20, MarginR: 20, MarginV: 40, Encoding: 1,
+		},
+		{
+			Name: "Translation", Fontname: "Microsoft YaHei", Fontsize: 56,
+			PrimaryColour: "&H0000FFFF", SecondaryColour: "&H000000FF", OutlineColour: "&H00000000", BackColour: "&H80000000",
+			ScaleX: 100, ScaleY: 100, BorderStyle: 1, Outline: 3, Shadow: 1,
+			Alignment: 2, MarginL: 20, MarginR: 20, MarginV: 110, Encoding: 1,
+		},
+	}
+}
+
+func assBool(b bool) string {
+	if b {
+		return "-1"
+	}
+	return "0"
+}
+
+func assFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (s Style) String() string {
+	return "Style: " + strings.Join([]string{
+		s.Name, s.Fontname, assFloat(s.Fontsize),
+		s.PrimaryColour, s.SecondaryColour, s.OutlineColour, s.BackColour,
+		assBool(s.Bold), assBool(s.Italic), assBool(s.Underline), assBool(s.StrikeOut),
+		assFloat(s.ScaleX), assFloat(s.ScaleY), assFloat(s.Spacing), assFloat(s.Angle),
+		strconv.Itoa(s.BorderStyle), assFloat(s.Outline), assFloat(s.Shadow),
+		strconv.Itoa(s.Alignment), strconv.Itoa(s.MarginL), strconv.Itoa(s.MarginR), strconv.Itoa(s.MarginV),
+		strconv.Itoa(s.Encoding),
+	}, ",")
+}
+
+// ParseStyle parses a "Style: ..." line in the V4+ field order
+func ParseStyle(line string) (Style, error) {
+	body, ok := strings.CutPrefix(strings.TrimSpace(line), "Style:")
+	if !ok {
+		return Style{}, errStyle
+	}
+	fields := strings.Split(body, ",")
+	if len(fields) != assStyleFields {
+		return Style{}, fmt.Errorf("%w: expected %d fields, got %d", errStyle, assStyleFields, len(fields))
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	var err error
+	float := func(s string) float64 {
+		f, e := strconv.ParseFloat(s, 64)
+		if e != nil && err == nil {
+			err = fmt.Errorf("%w: %v", errStyle, e)
+		}
+		return f
+	}
+	integer := func(s string) int {
+		return int(float(s))
+	}
+	boolean := func(s string) bool {
+		return integer(s) != 0
+	}
+
+	s := Style{
+		Name:            fields[0],
+		Fontname:        fields[1],
+		Fontsize:        float(fields[2]),
+		PrimaryColour:   fields[3],
+		SecondaryColour: fields[4],
+		OutlineColour:   fields[5],
+		BackColour:      fields[6],
+		Bold:            boolean(fields[7]),
+		Italic:          boolean(fields[8]),
+		Underline:       boolean(fields[9]),
+		StrikeOut:       boolean(fields[10]),
+		ScaleX:          float(fields[11]),
+		ScaleY:          float(fields[12]),
+		Spacing:         float(fields[13]),
+		Angle:           float(fields[14]),
+		BorderStyle:     integer(fields[15]),
+		Outline:         float(fields[16]),
+		Shadow:          float(fields[17]),
+		Alignment:       integer(fields[18]),
+		MarginL:         integer(fields[19]),
+		MarginR:         integer(fields[20]),
+		MarginV:         integer(fields[21]),
+		Encoding:        integer(fields[22]),
+	}
+	return s, err
+}
+
+// LoadStyles reads all "Style:" lines, so either a plain style list
+// or an existing .ass file can be used as the template.
+func LoadStyles(r io.Reader) ([]Style, error) {
+	var styles []Style
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if !strings.HasPrefix(line, "Style:") {
+			continue
+		}
+		style, err := ParseStyle(line)
+		if err != nil {
+			return nil, err
+		}
+		styles = append(styles, style)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(styles) == 0 {
+		return nil, fmt.Errorf("%w: no style found", errStyle)
+	}
+	return styles, nil
+}
+
+// ASS builds an Advanced SubStation Alpha file
+type ASS struct {
+	builder  strings.Builder
+	notes    []string
+	Title    string
+	PlayResX int
+	PlayResY int
+	// Styles of the [V4+ Styles] section,
+	// the first one is used by Append and AppendCue.
+	Styles []Style
+}
+
+// formatASSTime converts the duration to ASS Time
+// for example 137.99s -> 0:02:17.99
+func formatASSTime(d time.Duration) string {
+	hour, minute, second, milli := splitTime(d)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", hour, minute, second, milli/10)
+}
+
+func NewASS() *ASS {
+	return &ASS{
+		PlayResX: 1920,
+		PlayResY: 1080,
+		Styles:   DefaultStyles(),
+	}
+}
+
+// AddNote adds a comment line to the [Script Info] section
+func (a *ASS) AddNote(note string) {
+	for _, line := range strings.Split(note, "\n") {
+		a.notes = append(a.notes, "; "+line)
+	}
+}
+
+func (a *ASS) defaultStyle() string {
+	if len(a.Styles) == 0 {
+		return "Default"
+	}
+	return a.Styles[0].Name
+}
+
+// Append appends a dialogue with the first style
+func (a *ASS) Append(start, end time.Duration, content string) {
+	a.AppendStyled(start, end, a.defaultStyle(), content)
+}
+
+// AppendStyled appends a dialogue with the given style, an empty or inverted
+// time range is skipped.
+func (a *ASS) AppendStyled(start, end time.Duration, style, content string) {
+	if end <= start {
+		return
+	}
+	content = strings.ReplaceAll(content, "\n", "\\N")
+	a.builder.WriteString(fmt.Sprintf("Dialogue: 0,%s,%s,%s,,0,0,0,,%s\n",
+		formatASSTime(start), formatASSTime(end), style, content))
+}
+
+func (a *ASS) AppendCue(c Cue) {
+	a.Append(c.Start, c.End, c.Text())
+}
+
+func (a *ASS) String() string {
+	var b strings.Builder
+	b.WriteString("[Script Info]\n")
+	for _, note := range a.notes {
+		b.WriteString(note + "\n")
+	}
+	if a.Title != "" {
+		b.WriteString("Title: " + a.Title + "\n")
+	}
+	b.WriteString("ScriptType: v4.00+\n")
+	b.WriteString("WrapStyle: 0\n")
+	b.WriteString("ScaledBorderAndShadow: yes\n")
+	b.WriteString(fmt.Sprintf("PlayResX: %d\n", a.PlayResX))
+	b.WriteString(fmt.Sprintf("PlayResY: %d\n", a.PlayResY))
+	b.WriteString("\n[V4+ Styles]\n")
+	b.WriteString(assStyleFormat + "\n")
+	for _, style := range a.Styles {
+		b.WriteString(style.String() + "\n")
+	}
+	b.WriteString("\n[Events]\n")
+	b.WriteString(assEventFormat + "\n")
+	b.WriteString(a.builder.String())
+	return b.String()
+}
+
+func (a *ASS) Reset() {
+	a.builder.Reset()
+	a.notes = nil
+}
@@ -0,0 +1,41 @@
+package srt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestASS(t *testing.T) {
+	a := NewASS()
+	a.AppendCue(Cue{Start: 61500 * time.Millisecond, End: 3663 * time.Second, Lines: []string{"a", "b"}})
+	a.AppendStyled(time.Second, 2*time.Second, "Translation", "翻译")
+
+	out := a.String()
+	for _, want := range []string{
+		"[V4+ Styles]\n" + assStyleFormat + "\nStyle: Default,Arial,64,",
+		"Dialogue: 0,0:01:01.50,1:01:03.00,Default,,0,0,0,,a\\Nb\n",
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Translation,,0,0,0,,翻译\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestLoadStyles(t *testing.T) {
+	var b strings.Builder
+	for _, style := range DefaultStyles() {
+		b.WriteString(style.String() + "\n")
+	}
+	styles, err := LoadStyles(strings.NewReader("[V4+ Styles]\n" + assStyleFormat + "\n" + b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(styles) != 2 || styles[1] != DefaultStyles()[1] {
+		t.Errorf("round trip failed: %+v", styles)
+	}
+	if _, err := ParseStyle("Style: Default,Arial,64"); err == nil {
+		t.Error("expected error of the short style")
+	}
+}
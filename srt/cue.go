@@ -0,0 +1,27 @@
+package srt
+
+import (
+	"strings"
+	"time"
+)
+
+// Cue is one subtitle entry
+type Cue struct {
+	Index int // the index parsed from the file, 0 if it's missing or malformed
+	Start time.Duration
+	End   time.Duration
+	Lines []string
+	// Settings are the WebVTT cue settings, e.g. "align:start",
+	// ignored by the formats that don't support them.
+	Settings string
+}
+
+// Text returns the lines joined by new lines
+func (c Cue) Text() string {
+	return strings.Join(c.Lines, "\n")
+}
+
+// Duration returns how long the cue is shown
+func (c Cue) Duration() time.Duration {
+	return c.End - c.Start
+}
@@ -0,0 +1,247 @@
+package srt
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// noLineStart are the CJK punctuations which shouldn't start a line
+const noLineStart = "、。，．,.！？!?：:；;」』）)】》〉ー…ゃゅょっァィゥェォャュョッ"
+
+// Layout wraps the text of cues and splits the cues which are too long to read
+type Layout struct {
+	// MaxChars is the max width of a line, a CJK (wide) character counts as 2, 0 disables wrapping
+	MaxChars int
+	// MaxLines is the max lines of a cue, the rest is moved to a new cue, 0 means no limit
+	MaxLines int
+	// MaxCPS is the max characters per second, a multi-line cue exceeding it
+	// is split into one line per cue, 0 means no limit
+	MaxCPS float64
+}
+
+// runeWidth returns 2 for the east asian wide characters, 1 for others
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// TextWidth returns the display width of s, a CJK character counts as 2
+func TextWidth(s string) int {
+	var w int
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// charCount counts the characters of the lines without spaces
+func charCount(lines []string) int {
+	var n int
+	for _, line := range lines {
+		for _, r := range line {
+			if !unicode.IsSpace(r) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// CPS returns the characters per second of the cue, spaces are not counted
+func (c Cue) CPS() float64 {
+	seconds := c.Duration().Seconds()
+	if seconds <= 0 {
+		return math.Inf(1)
+	}
+	return float64(charCount(c.Lines)) / seconds
+}
+
+type token struct {
+	text        string
+	width       int
+	spaceBefore bool
+}
+
+// tokenize splits the text into words, every CJK character is a word itself
+// and the punctuations which can't start a line are glued to the previous word.
+// A newline is a soft break: it becomes a space between two narrow words
+// and disappears between CJK characters.
+func tokenize(text string) []token {
+	var tokens []token
+	var word strings.Builder
+	space, soft := false, false
+
+	// spaceBefore decides the space before the new word starting with r
+	spaceBefore := func(r rune) bool {
+		if space {
+			return true
+		}
+		if !soft || len(tokens) == 0 {
+			return false
+		}
+		last, _ := utf8.DecodeLastRuneInString(tokens[len(tokens)-1].text)
+		return runeWidth(last) == 1 && runeWidth(r) == 1
+	}
+	add := func(tok token) {
+		tokens = append(tokens, tok)
+		space, soft = false, false
+	}
+	flush := func() {
+		if word.Len() > 0 {
+			first, _ := utf8.DecodeRuneInString(word.String())
+			add(token{text: word.String(), width: TextWidth(word.String()), spaceBefore: spaceBefore(first)})
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			flush()
+			soft = len(tokens) > 0
+		case unicode.IsSpace(r):
+			flush()
+			space = len(tokens) > 0
+		case strings.ContainsRune(noLineStart, r) && !space && (word.Len() > 0 || len(tokens) > 0):
+			if word.Len() > 0 {
+				word.WriteRune(r)
+				continue
+			}
+			last := &tokens[len(tokens)-1]
+			last.text += string(r)
+			last.width += runeWidth(r)
+			soft = false
+		case runeWidth(r) == 2:
+			flush()
+			add(token{text: string(r), width: 2, spaceBefore: spaceBefore(r)})
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Wrap wraps the text into lines no wider than MaxChars,
+// a word wider than MaxChars takes a line itself.
+func (l Layout) Wrap(text string) []string {
+	if l.MaxChars <= 0 {
+		return strings.Split(text, "\n")
+	}
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, tok := range tokenize(text) {
+		w := tok.width
+		if tok.spaceBefore && lineWidth > 0 {
+			w++
+		}
+		if lineWidth > 0 && lineWidth+w > l.MaxChars {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth, w = 0, tok.width
+		}
+		if tok.spaceBefore && lineWidth > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(tok.text)
+		lineWidth += w
+	}
+	if line.Len() > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// splitByLines splits the cue into groups of lines,
+// the time is distributed proportionally to the characters of each group.
+func splitByLines(c Cue, groups [][]string) []Cue {
+	if len(groups) == 1 {
+		c.Lines = groups[0]
+		return []Cue{c}
+	}
+	var total int
+	counts := make([]int, len(groups))
+	for i, group := range groups {
+		counts[i] = charCount(group)
+		total += counts[i]
+	}
+
+	cues := make([]Cue, 0, len(groups))
+	start := c.Start
+	var done int
+	for i, group := range groups {
+		done += counts[i]
+		end := c.End
+		if i < len(groups)-1 && total > 0 {
+			end = c.Start + time.Duration(float64(c.Duration())*float64(done)/float64(total)).Truncate(time.Millisecond)
+		}
+		cues = append(cues, Cue{Start: start, End: end, Lines: group, Settings: c.Settings})
+		start = end
+	}
+	return cues
+}
+
+// slowDown returns the end time for the cue to be read within MaxCPS,
+// the cue is extended into the following silence, but not past limit.
+func (l Layout) slowDown(c Cue, pieces int, limit time.Duration) time.Duration {
+	if l.MaxCPS <= 0 || c.CPS() <= l.MaxCPS {
+		return c.End
+	}
+	// plus 1ms per piece for the split points truncated to milliseconds
+	need := time.Duration(math.Ceil(float64(charCount(c.Lines))/l.MaxCPS*1000)+float64(pieces)) * time.Millisecond
+	return max(c.End, min(c.Start+need, limit))
+}
+
+// Apply wraps every cue, then splits the cues having more than MaxLines lines.
+// A cue exceeding MaxCPS is split into one line per cue, a single line is wrapped
+// into two first, and the cue is extended into the silence before the next cue
+// so that every piece can be read within MaxCPS. The split cues are renumbered by the writers.
+func (l Layout) Apply(cues []Cue) []Cue {
+	laid := make([]Cue, 0, len(cues))
+	for i, c := range cues {
+		lines := c.Lines
+		if l.MaxChars > 0 {
+			lines = l.Wrap(strings.Join(c.Lines, "\n"))
+		}
+
+		size := len(lines)
+		if l.MaxLines > 0 && size > l.MaxLines {
+			size = l.MaxLines
+		}
+		c.Lines = lines
+		if l.MaxCPS > 0 && c.CPS() > l.MaxCPS {
+			if len(lines) == 1 {
+				half := Layout{MaxChars: (TextWidth(lines[0]) + 1) / 2}
+				lines = half.Wrap(lines[0])
+				c.Lines = lines
+			}
+			size = 1
+		}
+
+		var groups [][]string
+		for i := 0; i < len(lines); i += size {
+			groups = append(groups, lines[i:min(i+size, len(lines))])
+		}
+		if len(groups) == 0 {
+			laid = append(laid, c)
+			continue
+		}
+
+		// the next cue may already overlap, then there is no silence to extend into
+		limit := time.Duration(math.MaxInt64)
+		if i+1 < len(cues) {
+			limit = max(cues[i+1].Start, c.End)
+		}
+		c.End = l.slowDown(c, len(groups), limit)
+		laid = append(laid, splitByLines(c, groups)...)
+	}
+	return laid
+}
@@ -0,0 +1,93 @@
+package srt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWrap(t *testing.T) {
+	l := Layout{MaxChars: 16}
+	tests := map[string][]string{
+		"the quick brown fox jumps over the lazy dog": {"the quick brown", "fox jumps over", "the lazy dog"},
+		"今日はいい天気ですね。散歩しましょう。":                         {"今日はいい天気で", "すね。散歩しま", "しょう。"},
+		"supercalifragilisticexpialidocious word":     {"supercalifragilisticexpialidocious", "word"},
+		"": {""},
+	}
+	for text, want := range tests {
+		if got := l.Wrap(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %q, got %q", text, want, got)
+		}
+	}
+}
+
+func TestLayoutApply(t *testing.T) {
+	cue := Cue{Start: 0, End: 4 * time.Second, Lines: []string{"aaaa bbbb cccc dddd"}}
+
+	cues := Layout{MaxChars: 9, MaxLines: 1}.Apply([]Cue{cue})
+	if len(cues) != 2 || cues[0].End != 2*time.Second || cues[1].Start != 2*time.Second ||
+		cues[1].End != 4*time.Second || cues[1].Text() != "cccc dddd" {
+		t.Errorf("unexpected split by lines: %+v", cues)
+	}
+
+	cues = Layout{MaxChars: 9, MaxLines: 2}.Apply([]Cue{cue})
+	if len(cues) != 1 || len(cues[0].Lines) != 2 {
+		t.Errorf("unexpected wrap: %+v", cues)
+	}
+
+	cues = Layout{MaxChars: 9, MaxLines: 2, MaxCPS: 3}.Apply([]Cue{cue})
+	if len(cues) != 2 {
+		t.Errorf("unexpected split by cps: %+v", cues)
+	}
+	for _, c := range cues {
+		if c.CPS() > 3 {
+			t.Errorf("%q is still read at %.2f cps", c.Text(), c.CPS())
+		}
+	}
+}
+
+func TestLayoutMaxCPS(t *testing.T) {
+	long := Cue{Start: 0, End: 2 * time.Second, Lines: []string{"a long line from google without any wrapping"}}
+	next := Cue{Start: 10 * time.Second, End: 11 * time.Second, Lines: []string{"next"}}
+	l := Layout{MaxCPS: 15}
+
+	cues := l.Apply([]Cue{long, next})
+	last := len(cues) - 2
+	if last < 1 {
+		t.Fatalf("the single line should be split: %+v", cues)
+	}
+	for _, c := range cues[:last+1] {
+		if c.CPS() > l.MaxCPS {
+			t.Errorf("%q is still read at %.2f cps", c.Text(), c.CPS())
+		}
+	}
+	if cues[last].End > next.Start || !reflect.DeepEqual(cues[last+1], next) {
+		t.Errorf("extended into the next cue: %+v", cues)
+	}
+
+	// not enough silence, extended to the next cue only
+	next.Start = 2200 * time.Millisecond
+	cues = l.Apply([]Cue{long, next})
+	if end := cues[len(cues)-2].End; end != next.Start {
+		t.Errorf("should be extended to the next cue only: %v", end)
+	}
+	overlapped := next
+	overlapped.Start = time.Second
+	cues = l.Apply([]Cue{long, overlapped})
+	if end := cues[len(cues)-2].End; end != long.End {
+		t.Errorf("should not be extended into an overlapping cue: %v", end)
+	}
+}
+
+func TestLayoutJoinLines(t *testing.T) {
+	cues := Layout{MaxChars: 40}.Apply([]Cue{
+		{Start: 0, End: time.Second, Lines: []string{"日本語", "テスト"}},
+		{Start: time.Second, End: 2 * time.Second, Lines: []string{"two", "lines"}},
+		{Start: 2 * time.Second, End: 3 * time.Second, Lines: []string{"日本語", "and English"}},
+	})
+	for i, want := range []string{"日本語テスト", "two lines", "日本語and English"} {
+		if got := cues[i].Text(); got != want {
+			t.Errorf("cue %d: expected %q, got %q", i, want, got)
+		}
+	}
+}
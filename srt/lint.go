@@ -0,0 +1,142 @@
+package srt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// IssueKind is the kind of a lint issue
+type IssueKind string
+
+const (
+	IssueEncoding  IssueKind = "encoding"   // invalid UTF-8, BOM, NUL or replacement characters
+	IssueParse     IssueKind = "parse"      // the file can't be parsed
+	IssueNumbering IssueKind = "numbering"  // the index is missing, malformed or not continuous
+	IssueEmpty     IssueKind = "empty"      // the cue has no text
+	IssueDuration  IssueKind = "duration"   // the end is not after the start
+	IssueOrder     IssueKind = "order"      // the cue starts before the previous one
+	IssueOverlap   IssueKind = "overlap"    // the cue starts before the previous one ends
+	IssueLineWidth IssueKind = "line-width" // a line is wider than MaxChars
+	IssueCPS       IssueKind = "cps"        // the reading speed exceeds MaxCPS
+)
+
+// Issue is a problem found by Lint
+type Issue struct {
+	Cue     int       `json:"cue"` // the position of the cue starting from 1, 0 for the whole file
+	Kind    IssueKind `json:"kind"`
+	Message string    `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Cue == 0 {
+		return fmt.Sprintf("%s: %s", i.Kind, i.Message)
+	}
+	return fmt.Sprintf("cue %d: %s: %s", i.Cue, i.Kind, i.Message)
+}
+
+// LintOptions are the limits checked by Lint, 0 disables the check
+type LintOptions struct {
+	MaxChars int
+	MaxCPS   float64
+}
+
+// lintEncoding checks the raw file and returns the valid UTF-8 content
+func lintEncoding(data []byte) ([]Issue, []byte) {
+	var issues []Issue
+	if bytes.HasPrefix(data, []byte("\ufeff")) {
+		issues = append(issues, Issue{Kind: IssueEncoding, Message: "UTF-8 BOM found"})
+	}
+	if !utf8.Valid(data) {
+		issues = append(issues, Issue{Kind: IssueEncoding, Message: "invalid UTF-8, the file may be in a legacy encoding like GBK or Shift-JIS"})
+		data = bytes.ToValidUTF8(data, []byte("\uFFFD"))
+	} else if bytes.ContainsRune(data, utf8.RuneError) {
+		issues = append(issues, Issue{Kind: IssueEncoding, Message: "replacement character U+FFFD found"})
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		issues = append(issues, Issue{Kind: IssueEncoding, Message: "NUL byte found, the file may be UTF-16"})
+		data = bytes.ReplaceAll(data, []byte{0}, nil)
+	}
+	return issues, data
+}
+
+// Lint checks a SubRip file and returns the issues with the parsed cues,
+// the cues are nil if the file can't be parsed.
+func Lint(data []byte, opts LintOptions) ([]Issue, []Cue) {
+	issues, data := lintEncoding(data)
+
+	cues, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return append(issues, Issue{Kind: IssueParse, Message: err.Error()}), nil
+	}
+
+	for i, c := range cues {
+		pos := i + 1
+		add := func(kind IssueKind, format string, args ...any) {
+			issues = append(issues, Issue{Cue: pos, Kind: kind, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if c.Index != pos {
+			if c.Index == 0 {
+				add(IssueNumbering, "missing or malformed index, expected %d", pos)
+			} else {
+				add(IssueNumbering, "index %d, expected %d", c.Index, pos)
+			}
+		}
+		if strings.TrimSpace(c.Text()) == "" {
+			add(IssueEmpty, "no text")
+		}
+		if c.End <= c.Start {
+			add(IssueDuration, "ends at %s, not after the start %s", formatTime(c.End), formatTime(c.Start))
+		}
+		if i > 0 {
+			prev := cues[i-1]
+			if c.Start < prev.Start {
+				add(IssueOrder, "starts at %s, before the previous cue %s", formatTime(c.Start), formatTime(prev.Start))
+			} else if c.Start < prev.End {
+				add(IssueOverlap, "starts at %s, before the previous cue ends %s", formatTime(c.Start), formatTime(prev.End))
+			}
+		}
+		if opts.MaxChars > 0 {
+			for n, line := range c.Lines {
+				if w := TextWidth(line); w > opts.MaxChars {
+					add(IssueLineWidth, "line %d is %d wide, max %d", n+1, w, opts.MaxChars)
+				}
+			}
+		}
+		if opts.MaxCPS > 0 && c.End > c.Start {
+			if cps := c.CPS(); cps > opts.MaxCPS {
+				add(IssueCPS, "%.1f characters per second, max %.1f", cps, opts.MaxCPS)
+			}
+		}
+	}
+	return issues, cues
+}
+
+// Fix drops the empty and inverted cues, resolves the order and overlaps
+// with the timing normalizer and wraps the lines wider than MaxChars.
+// The encoding and the numbering are fixed by writing the cues again.
+func Fix(cues []Cue, opts LintOptions, timing Timing) []Cue {
+	fixed := make([]Cue, 0, len(cues))
+	for _, c := range cues {
+		if strings.TrimSpace(c.Text()) == "" || c.End <= c.Start {
+			continue
+		}
+		fixed = append(fixed, c)
+	}
+	fixed = timing.Normalize(fixed)
+
+	if opts.MaxChars > 0 {
+		layout := Layout{MaxChars: opts.MaxChars}
+		for i, c := range fixed {
+			for _, line := range c.Lines {
+				if TextWidth(line) > opts.MaxChars {
+					fixed[i].Lines = layout.Wrap(strings.Join(c.Lines, "\n"))
+					break
+				}
+			}
+		}
+	}
+	return fixed
+}
@@ -0,0 +1,52 @@
+package srt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	input := "\ufeff1\n00:00:01,000 --> 00:00:03,000\nhello\n\n" +
+		"3\n00:00:02,000 --> 00:00:04,000\nthis line is definitely too long\n\n" +
+		"3\n00:00:00,500 --> 00:00:00,400\n\n\n" +
+		"4\n00:00:05,000 --> 00:00:05,500\nfast fast fast fast\n\n"
+	issues, cues := Lint([]byte(input), LintOptions{MaxChars: 20, MaxCPS: 20})
+	if len(cues) != 4 {
+		t.Fatalf("expected 4 cues, got %d", len(cues))
+	}
+
+	want := map[IssueKind]int{
+		IssueEncoding:  1,
+		IssueNumbering: 1,
+		IssueOverlap:   1,
+		IssueLineWidth: 1,
+		IssueEmpty:     1,
+		IssueDuration:  1,
+		IssueOrder:     1,
+		IssueCPS:       1,
+	}
+	got := map[IssueKind]int{}
+	for _, issue := range issues {
+		got[issue.Kind]++
+	}
+	for kind, n := range want {
+		if got[kind] != n {
+			t.Errorf("expected %d %s issues, got %d: %v", n, kind, got[kind], issues)
+		}
+	}
+
+	fixed := Fix(cues, LintOptions{MaxChars: 20}, Timing{})
+	var b strings.Builder
+	Encode(&b, fixed)
+	issues, _ = Lint([]byte(b.String()), LintOptions{MaxChars: 20})
+	if len(fixed) != 3 || len(issues) != 0 {
+		t.Errorf("unexpected issues after fix: %v", issues)
+	}
+}
+
+func TestLintEncoding(t *testing.T) {
+	issues, cues := Lint([]byte("1\n00:00:01,000 --> 00:00:02,000\n\x82\xa0\n"), LintOptions{})
+	if len(cues) != 1 || len(issues) != 1 || issues[0].Kind != IssueEncoding {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+}
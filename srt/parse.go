@@ -0,0 +1,162 @@
+package srt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errTimestamp = errors.New("unknown timestamp")
+	errTiming    = errors.New("missing timing line")
+	errStrayText = errors.New("text outside of any cue")
+)
+
+// ParseError reports which line of the file is malformed
+type ParseError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v: %q", e.Line, e.Err, e.Text)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseTimestamp parses SubRip time like 00:02:17,990,
+// dot milliseconds (00:02:17.990) and missing hours (02:17,990) are accepted as well.
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	clock, frac, hasFrac := strings.Cut(strings.Replace(s, ",", ".", 1), ".")
+
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errTimestamp
+	}
+	var d time.Duration
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, errTimestamp
+		}
+		d = d*60 + time.Duration(n)
+	}
+	d *= time.Second
+
+	if hasFrac {
+		if frac == "" || len(frac) > 9 {
+			return 0, errTimestamp
+		}
+		n, err := strconv.ParseUint(frac, 10, 32)
+		if err != nil {
+			return 0, errTimestamp
+		}
+		// Padding to nanoseconds
+		for i := len(frac); i < 9; i++ {
+			n *= 10
+		}
+		d += time.Duration(n)
+	}
+	return d, nil
+}
+
+// parseTiming parses the timing line, e.g. 00:00:01,000 --> 00:00:02,500,
+// anything after the end timestamp (like SubRip coordinates) is returned as settings.
+func parseTiming(line string) (start, end time.Duration, settings string, err error) {
+	from, to, ok := strings.Cut(line, "-->")
+	if !ok {
+		return 0, 0, "", errTiming
+	}
+	to = strings.TrimSpace(to)
+	if i := strings.IndexAny(to, " \t"); i >= 0 {
+		to, settings = to[:i], strings.TrimSpace(to[i:])
+	}
+	if start, err = parseTimestamp(from); err != nil {
+		return
+	}
+	end, err = parseTimestamp(to)
+	return
+}
+
+func isTiming(line string) bool {
+	return strings.Contains(line, "-->")
+}
+
+// readLines reads all lines without the BOM and the trailing CR
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	return lines, scanner.Err()
+}
+
+// Parse reads a SubRip file into cues.
+// BOMs, CRLF line endings, missing or malformed indices and multi-line text are tolerated,
+// an unparsable timing line or text outside of any cue is reported as *ParseError.
+func Parse(r io.Reader) ([]Cue, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cues []Cue
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+
+		var cue Cue
+		if !isTiming(line) {
+			if i+1 >= len(lines) || !isTiming(lines[i+1]) {
+				// Text after a blank line can't be told from a broken cue,
+				// report it rather than guessing where it belongs
+				if len(cues) > 0 {
+					return nil, &ParseError{Line: i + 1, Text: lines[i], Err: errStrayText}
+				}
+				return nil, &ParseError{Line: i + 1, Text: lines[i], Err: errTiming}
+			}
+			// Malformed index is kept as 0
+			cue.Index, _ = strconv.Atoi(line)
+			i++
+		}
+
+		start, end, _, err := parseTiming(lines[i])
+		if err != nil {
+			return nil, &ParseError{Line: i + 1, Text: lines[i], Err: err}
+		}
+		cue.Start, cue.End = start, end
+
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			// The next block starts without a blank line
+			if isTiming(lines[i+1]) ||
+				(i+2 < len(lines) && isTiming(lines[i+2]) && isIndex(lines[i+1])) {
+				break
+			}
+			i++
+			cue.Lines = append(cue.Lines, lines[i])
+		}
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
+
+func isIndex(line string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(line))
+	return err == nil
+}
@@ -0,0 +1,49 @@
+package srt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	input := "\ufeff1\r\n00:00:01,000 --> 00:00:02,500\r\nこんにちは\r\n世界\r\n\r\n" +
+		"x\r\n00:00:03.2 --> 00:00:04,000 X1:10 X2:20\r\nmalformed index\r\n\r\n" +
+		"00:00:05,000 --> 00:00:06,000\r\nmissing index\r\n" +
+		"4\r\n01:00:07,000 --> 01:00:08,000\r\nno blank line\r\nsecond line\r\n\r\n"
+
+	cues, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Index: 1, Start: time.Second, End: 2500 * time.Millisecond, Lines: []string{"こんにちは", "世界"}},
+		{Index: 0, Start: 3200 * time.Millisecond, End: 4 * time.Second, Lines: []string{"malformed index"}},
+		{Index: 0, Start: 5 * time.Second, End: 6 * time.Second, Lines: []string{"missing index"}},
+		{Index: 4, Start: time.Hour + 7*time.Second, End: time.Hour + 8*time.Second, Lines: []string{"no blank line", "second line"}},
+	}
+	if len(cues) != len(want) {
+		t.Fatalf("expected %d cues, got %d: %+v", len(want), len(cues), cues)
+	}
+	for i := range want {
+		if cues[i].Index != want[i].Index || cues[i].Start != want[i].Start ||
+			cues[i].End != want[i].End || cues[i].Text() != want[i].Text() {
+			t.Errorf("cue %d: expected %+v, got %+v", i, want[i], cues[i])
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("1\n00:00:01,000 --> 00:0a:02,000\ntext\n"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Fatalf("expected parse error at line 2, got %v", err)
+	}
+
+	// a stray line after a blank line is not merged into the previous cue
+	_, err = Parse(strings.NewReader("1\n00:00:01,000 --> 00:00:02,000\ntext\n\nstray\n"))
+	if !errors.As(err, &parseErr) || parseErr.Line != 5 || !errors.Is(err, errStrayText) {
+		t.Fatalf("expected stray text at line 5, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package srt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncPoint maps the time From in the subtitle to the time To in the video
+type SyncPoint struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// ParseSyncPoint parses a sync point like 00:01:00,000=00:01:02,500
+func ParseSyncPoint(s string) (SyncPoint, error) {
+	from, to, ok := strings.Cut(s, "=")
+	if !ok {
+		return SyncPoint{}, fmt.Errorf("invalid sync point %q, expected FROM=TO", s)
+	}
+	var p SyncPoint
+	var err error
+	if p.From, err = parseTimestamp(from); err != nil {
+		return SyncPoint{}, fmt.Errorf("invalid sync point %q: %w", s, err)
+	}
+	if p.To, err = parseTimestamp(to); err != nil {
+		return SyncPoint{}, fmt.Errorf("invalid sync point %q: %w", s, err)
+	}
+	return p, nil
+}
+
+// ParseFramerate parses a framerate conversion like 23.976:25 to the scale factor
+func ParseFramerate(s string) (float64, error) {
+	from, to, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid framerate %q, expected FROM:TO", s)
+	}
+	f, err1 := strconv.ParseFloat(from, 64)
+	t, err2 := strconv.ParseFloat(to, 64)
+	if err1 != nil || err2 != nil || f <= 0 || t <= 0 {
+		return 0, fmt.Errorf("invalid framerate %q", s)
+	}
+	return FramerateFactor(f, t), nil
+}
+
+// FramerateFactor returns the scale factor converting the subtitle of
+// a video played at from fps to the same video played at to fps,
+// e.g. 23.976 -> 25 (PAL speedup) makes every timestamp shorter.
+func FramerateFactor(from, to float64) float64 {
+	return from / to
+}
+
+// retime maps every timestamp, negative timestamps are clamped to 0
+func retime(cues []Cue, mapping func(time.Duration) time.Duration) []Cue {
+	retimed := make([]Cue, len(cues))
+	for i, c := range cues {
+		c.Start = max(mapping(c.Start), 0).Truncate(time.Millisecond)
+		c.End = max(mapping(c.End), 0).Truncate(time.Millisecond)
+		retimed[i] = c
+	}
+	return retimed
+}
+
+// Shift moves all cues by the offset, which can be negative
+func Shift(cues []Cue, offset time.Duration) []Cue {
+	return retime(cues, func(d time.Duration) time.Duration {
+		return d + offset
+	})
+}
+
+// Scale multiplies all timestamps by the factor, see FramerateFactor
+func Scale(cues []Cue, factor float64) []Cue {
+	return retime(cues, func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) * factor)
+	})
+}
+
+// Sync maps all timestamps linearly so that a.From -> a.To and b.From -> b.To,
+// which fixes both the offset and the drift.
+func Sync(cues []Cue, a, b SyncPoint) ([]Cue, error) {
+	if a.From == b.From {
+		return nil, errors.New("the two sync points must be at different times")
+	}
+	factor := float64(b.To-a.To) / float64(b.From-a.From)
+	if factor <= 0 {
+		return nil, errors.New("the two sync points must be in the same order")
+	}
+	return retime(cues, func(d time.Duration) time.Duration {
+		return a.To + time.Duration(float64(d-a.From)*factor)
+	}), nil
+}
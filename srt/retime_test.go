@@ -0,0 +1,43 @@
+package srt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetime(t *testing.T) {
+	cues := []Cue{{Start: 10 * time.Second, End: 20 * time.Second}}
+
+	shifted := Shift(cues, -15*time.Second)
+	if shifted[0].Start != 0 || shifted[0].End != 5*time.Second {
+		t.Errorf("unexpected shift: %+v", shifted[0])
+	}
+
+	factor, err := ParseFramerate("25:23.976")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaled := Scale(cues, factor)
+	if scaled[0].End != 20854*time.Millisecond {
+		t.Errorf("unexpected scale: %+v", scaled[0])
+	}
+
+	a, _ := ParseSyncPoint("00:00:10,000=00:00:12,000")
+	b, err := ParseSyncPoint("00:00:20,000=00:00:32,000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	synced, err := Sync([]Cue{{Start: 15 * time.Second, End: 30 * time.Second}}, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced[0].Start != 22*time.Second || synced[0].End != 52*time.Second {
+		t.Errorf("unexpected sync: %+v", synced[0])
+	}
+	if _, err := Sync(cues, a, a); err == nil {
+		t.Error("expected error of the same sync points")
+	}
+	if cues[0].Start != 10*time.Second {
+		t.Error("the input must not be modified")
+	}
+}
@@ -0,0 +1,59 @@
+package srt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SBV builds a YouTube SubViewer file
+type SBV struct {
+	builder strings.Builder
+}
+
+// formatSBVTime converts the duration to SBV Time
+// for example 137.99s -> 0:02:17.990
+func formatSBVTime(d time.Duration) string {
+	hour, minute, second, milli := splitTime(d)
+	return fmt.Sprintf("%d:%02d:%02d.%03d", hour, minute, second, milli)
+}
+
+func NewSBV() *SBV {
+	return &SBV{}
+}
+
+// Append appends a cue with the given content, an empty or inverted
+// time range is skipped.
+func (s *SBV) Append(start, end time.Duration, content string) {
+	if end <= start {
+		return
+	}
+	s.builder.WriteString(formatSBVTime(start) + "," + formatSBVTime(end))
+	s.builder.WriteString("\n")
+	s.builder.WriteString(content)
+	s.builder.WriteString("\n")
+	s.builder.WriteString("\n")
+}
+
+func (s *SBV) AppendCue(c Cue) {
+	s.Append(c.Start, c.End, c.Text())
+}
+
+func (s *SBV) String() string {
+	return s.builder.String()
+}
+
+func (s *SBV) Reset() {
+	s.builder.Reset()
+}
+
+// EncodeSBV writes the cues as a SBV file
+func EncodeSBV(w io.Writer, cues []Cue) error {
+	s := NewSBV()
+	for _, c := range cues {
+		s.AppendCue(c)
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
@@ -1,10 +1,11 @@
 package srt
 
 import (
-	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type SRT struct {
@@ -12,42 +13,30 @@ type SRT struct {
 	count   uint64
 }
 
-// formatTime function converts the internal time to SubRip Time
-// for example 137.99 -> 00:02:17,990
-func formatTime(internalTime string) (string, error) {
-	_parseTime := strings.Split(internalTime, ".")
-	if len(_parseTime) != 2 {
-		return "", errors.New("unknown timestamp")
-	}
-	intTime, err := strconv.ParseInt(_parseTime[0], 10, 64)
-	if err != nil {
-		return "", err
-	}
-	milliTime := _parseTime[1]
-	// Cut off the string if it's overflow.
-	if len(milliTime) > 3 {
-		milliTime = milliTime[:3]
-	}
-	// Padding to the length 3
-	for len(milliTime) < 3 {
-		milliTime += "0"
-	}
-	var hour int64
-	var minute int64
-	if intTime > 3600 {
-		hour = intTime / 3600
-		intTime -= 3600 * hour
-	}
-	if intTime > 60 {
-		minute = intTime / 60
-		intTime -= 60 * minute
+// FromSeconds converts the internal float seconds to time.Duration,
+// rounded to milliseconds which is the SubRip precision.
+func FromSeconds(sec float64) time.Duration {
+	return (time.Duration(sec*float64(time.Second)) + time.Millisecond/2).Truncate(time.Millisecond)
+}
+
+// splitTime splits the duration into clock parts
+func splitTime(d time.Duration) (hour, minute, second, milli int64) {
+	if d < 0 {
+		d = 0
 	}
+	ms := d.Milliseconds()
+	hour = ms / 3600000
+	minute = ms / 60000 % 60
+	second = ms / 1000 % 60
+	milli = ms % 1000
+	return
+}
 
-	return fmt.Sprintf("%02d:%02d:%02d,%s",
-		hour,
-		minute,
-		intTime,
-		milliTime), nil
+// formatTime function converts the duration to SubRip Time
+// for example 137.99s -> 00:02:17,990
+func formatTime(d time.Duration) string {
+	hour, minute, second, milli := splitTime(d)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", hour, minute, second, milli)
 }
 
 func New() *SRT {
@@ -56,15 +45,15 @@ func New() *SRT {
 	}
 }
 
-func (s *SRT) Append(start, end, content string) {
-	s.builder.WriteString(strconv.FormatUint(s.count, 10))
-	s.builder.WriteString("\n")
-	start_, err1 := formatTime(start)
-	_end, err2 := formatTime(end)
-	if err1 != nil || err2 != nil {
+// Append appends a cue with the given content, an empty or inverted
+// time range is skipped without consuming an index.
+func (s *SRT) Append(start, end time.Duration, content string) {
+	if end <= start {
 		return
 	}
-	s.builder.WriteString(start_ + " --> " + _end)
+	s.builder.WriteString(strconv.FormatUint(s.count, 10))
+	s.builder.WriteString("\n")
+	s.builder.WriteString(formatTime(start) + " --> " + formatTime(end))
 	s.builder.WriteString("\n")
 	s.builder.WriteString(content)
 	s.builder.WriteString("\n")
@@ -72,6 +61,11 @@ func (s *SRT) Append(start, end, content string) {
 	s.count++
 }
 
+// AppendCue appends the cue, its index is renumbered.
+func (s *SRT) AppendCue(c Cue) {
+	s.Append(c.Start, c.End, c.Text())
+}
+
 func (s *SRT) String() string {
 	return s.builder.String()
 }
@@ -80,3 +74,13 @@ func (s *SRT) Reset() {
 	s.builder.Reset()
 	s.count = 1
 }
+
+// Encode writes the cues as a SubRip file
+func Encode(w io.Writer, cues []Cue) error {
+	s := New()
+	for _, c := range cues {
+		s.AppendCue(c)
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
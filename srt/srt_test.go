@@ -1,7 +1,48 @@
 package srt
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestSRT(t *testing.T) {
-	t.Log(formatTime("43.280000000001166"))
+	t.Log(formatTime(FromSeconds(43.280000000001166)))
+}
+
+func TestFormatTime(t *testing.T) {
+	tests := map[float64]string{
+		12:     "00:00:12,000",
+		60:     "00:01:00,000",
+		137.99: "00:02:17,990",
+		3600:   "01:00:00,000",
+		3661.5: "01:01:01,500",
+		0.9996: "00:00:01,000",
+	}
+	for sec, want := range tests {
+		if got := formatTime(FromSeconds(sec)); got != want {
+			t.Errorf("%v: expected %s, got %s", sec, want, got)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	cues := []Cue{
+		{Start: time.Second, End: 2 * time.Second, Lines: []string{"a", "b"}},
+		{Start: 3 * time.Second, End: 3 * time.Second, Lines: []string{"empty range"}},
+		{Start: 4 * time.Second, End: 5 * time.Second, Lines: []string{"c"}},
+	}
+	var b strings.Builder
+	if err := Encode(&b, cues); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,000\na\nb\n\n2\n00:00:04,000 --> 00:00:05,000\nc\n\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+
+	parsed, err := Parse(strings.NewReader(b.String()))
+	if err != nil || len(parsed) != 2 || parsed[1].Index != 2 || parsed[1].Start != 4*time.Second {
+		t.Errorf("round trip failed: %+v %v", parsed, err)
+	}
 }
@@ -0,0 +1,89 @@
+package srt
+
+import (
+	"sort"
+	"time"
+)
+
+// Timing fixes the timing of cues before writing,
+// overlapped cues are always resolved even if all the limits are 0.
+type Timing struct {
+	// MinDuration is the min duration of a cue, shorter cues are extended into the silence around
+	MinDuration time.Duration
+	// MinGap is the min gap between two cues
+	MinGap time.Duration
+}
+
+// Normalize returns the cues sorted by the start time with:
+//  1. overlaps resolved at the middle of the overlapped range, since
+//     every region is padded by the same overlap on both sides,
+//     a cue containing the next one ends before it, and two cues
+//     starting at the same time are merged;
+//  2. at least MinGap between two cues;
+//  3. cues shorter than MinDuration extended into the following silence,
+//     then into the previous silence if it's still too short.
+func (t Timing) Normalize(cues []Cue) []Cue {
+	timed := make([]Cue, len(cues))
+	copy(timed, cues)
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].Start < timed[j].Start
+	})
+
+	for i := 0; i+1 < len(timed); i++ {
+		cur, next := &timed[i], &timed[i+1]
+		if cur.End+t.MinGap <= next.Start {
+			continue
+		}
+		// Only the start of next can be moved, it must stay before the cue after next
+		limit := next.End
+		if i+2 < len(timed) {
+			limit = min(limit, timed[i+2].Start)
+		}
+		split := (cur.End + next.Start) / 2
+		end, start := split-t.MinGap/2, split+t.MinGap-t.MinGap/2
+		// Don't let the cues become empty
+		if end <= cur.Start {
+			end = min(cur.End, next.Start)
+			start = max(end+t.MinGap, next.Start)
+		}
+		if cur.End >= limit || start >= limit {
+			// cur contains next or reaches the cue after it, end it before next
+			end, start = next.Start-t.MinGap, next.Start
+		}
+		if end <= cur.Start {
+			// Both start at the same time, show them as one cue
+			cur.Lines = append(cur.Lines[:len(cur.Lines):len(cur.Lines)], next.Lines...)
+			cur.End = max(cur.End, next.End)
+			timed = append(timed[:i+1], timed[i+2:]...)
+			i--
+			continue
+		}
+		cur.End, next.Start = end, start
+	}
+
+	if t.MinDuration <= 0 {
+		return timed
+	}
+	for i := range timed {
+		cur := &timed[i]
+		if cur.Duration() >= t.MinDuration {
+			continue
+		}
+		// Extend into the following silence
+		limit := cur.Start + t.MinDuration
+		if i+1 < len(timed) {
+			limit = min(limit, timed[i+1].Start-t.MinGap)
+		}
+		cur.End = max(cur.End, limit)
+		if cur.Duration() >= t.MinDuration {
+			continue
+		}
+		// Then the previous silence
+		limit = max(cur.End-t.MinDuration, 0)
+		if i > 0 {
+			limit = max(limit, timed[i-1].End+t.MinGap)
+		}
+		cur.Start = min(cur.Start, limit)
+	}
+	return timed
+}
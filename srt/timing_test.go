@@ -0,0 +1,85 @@
+package srt
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestNormalize(t *testing.T) {
+	cues := []Cue{
+		{Start: ms(5000), End: ms(5300), Lines: []string{"short"}},
+		{Start: ms(0), End: ms(2250), Lines: []string{"a"}},
+		{Start: ms(1750), End: ms(4000), Lines: []string{"overlap"}},
+		{Start: ms(9000), End: ms(9200), Lines: []string{"last"}},
+	}
+	timed := Timing{MinDuration: time.Second, MinGap: ms(100)}.Normalize(cues)
+
+	want := [][2]time.Duration{
+		{ms(0), ms(1950)},
+		{ms(2050), ms(4000)},
+		{ms(5000), ms(6000)},
+		{ms(9000), ms(10000)},
+	}
+	for i, w := range want {
+		if timed[i].Start != w[0] || timed[i].End != w[1] {
+			t.Errorf("cue %d: expected %v-%v, got %v-%v", i, w[0], w[1], timed[i].Start, timed[i].End)
+		}
+	}
+	if cues[0].Text() != "short" || cues[0].End != ms(5300) {
+		t.Error("the input must not be modified")
+	}
+}
+
+func TestNormalizeBlocked(t *testing.T) {
+	cues := []Cue{
+		{Start: ms(0), End: ms(1000)},
+		{Start: ms(1100), End: ms(1400)},
+		{Start: ms(1500), End: ms(3000)},
+	}
+	timed := Timing{MinDuration: time.Second, MinGap: ms(100)}.Normalize(cues)
+	// no silence to extend into
+	if timed[1].Start != ms(1100) || timed[1].End != ms(1400) {
+		t.Errorf("unexpected %v-%v", timed[1].Start, timed[1].End)
+	}
+}
+
+func TestNormalizeContained(t *testing.T) {
+	cues := []Cue{
+		{Start: ms(0), End: ms(10000), Lines: []string{"long"}},
+		{Start: ms(2000), End: ms(4000), Lines: []string{"contained"}},
+		{Start: ms(5000), End: ms(6000), Lines: []string{"also overlapped by long"}},
+		{Start: ms(20000), End: ms(25000), Lines: []string{"same start"}},
+		{Start: ms(20000), End: ms(22000), Lines: []string{"inside"}},
+	}
+	timed := Timing{MinGap: ms(100)}.Normalize(cues)
+
+	want := []struct {
+		start, end time.Duration
+		text       string
+	}{
+		{ms(0), ms(1900), "long"},
+		{ms(2000), ms(4000), "contained"},
+		{ms(5000), ms(6000), "also overlapped by long"},
+		{ms(20000), ms(25000), "same start\ninside"},
+	}
+	if len(timed) != len(want) {
+		t.Fatalf("expected %d cues, got %+v", len(want), timed)
+	}
+	for i, w := range want {
+		if timed[i].Start != w.start || timed[i].End != w.end || timed[i].Text() != w.text {
+			t.Errorf("cue %d: expected %v-%v %q, got %v-%v %q", i, w.start, w.end, w.text, timed[i].Start, timed[i].End, timed[i].Text())
+		}
+	}
+	for i := 0; i+1 < len(timed); i++ {
+		if timed[i].End+ms(100) > timed[i+1].Start {
+			t.Errorf("cue %d still overlaps the next one", i)
+		}
+	}
+	if len(cues[3].Lines) != 1 {
+		t.Error("the input must not be modified")
+	}
+}
@@ -0,0 +1,106 @@
+package srt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const ttmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ` +
+	`xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" ` +
+	`ttp:profile="http://www.w3.org/ns/ttml/profile/imsc1/text" xml:lang="%s">
+  <head>
+    <metadata>
+%s    </metadata>
+    <styling>
+      <style xml:id="default" tts:color="white" tts:fontFamily="proportionalSansSerif" tts:fontSize="100%%" tts:textAlign="center" tts:textOutline="black 5%%"/>
+    </styling>
+    <layout>
+      <region xml:id="bottom" tts:origin="10%% 10%%" tts:extent="80%% 80%%" tts:displayAlign="after"/>
+    </layout>
+  </head>
+  <body style="default" region="bottom">
+    <div>
+`
+
+const ttmlFooter = `    </div>
+  </body>
+</tt>
+`
+
+// TTML builds a TTML (IMSC1 text profile) file, also known as DFXP
+type TTML struct {
+	builder strings.Builder
+	notes   []string
+	// Lang is the xml:lang of the document
+	Lang string
+}
+
+// formatTTMLTime converts the duration to TTML clock time
+// for example 137.99s -> 00:02:17.990
+func formatTTMLTime(d time.Duration) string {
+	return formatVTTTime(d)
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func NewTTML() *TTML {
+	return &TTML{}
+}
+
+// AddNote adds a ttm:desc to the metadata
+func (t *TTML) AddNote(note string) {
+	t.notes = append(t.notes, note)
+}
+
+// Append appends a paragraph, an empty or inverted time range is skipped.
+func (t *TTML) Append(start, end time.Duration, content string) {
+	if end <= start {
+		return
+	}
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		lines[i] = escapeXML(lines[i])
+	}
+	t.builder.WriteString(fmt.Sprintf("      <p begin=\"%s\" end=\"%s\">%s</p>\n",
+		formatTTMLTime(start), formatTTMLTime(end), strings.Join(lines, "<br/>")))
+}
+
+func (t *TTML) AppendCue(c Cue) {
+	t.Append(c.Start, c.End, c.Text())
+}
+
+func (t *TTML) String() string {
+	var metadata strings.Builder
+	for _, note := range t.notes {
+		metadata.WriteString("      <ttm:desc>" + escapeXML(note) + "</ttm:desc>\n")
+	}
+	lang := t.Lang
+	if lang == "" {
+		lang = "und"
+	}
+	return fmt.Sprintf(ttmlHeader, escapeXML(lang), metadata.String()) + t.builder.String() + ttmlFooter
+}
+
+func (t *TTML) Reset() {
+	t.builder.Reset()
+	t.notes = nil
+}
+
+// EncodeTTML writes the cues as a TTML file
+func EncodeTTML(w io.Writer, cues []Cue, lang string) error {
+	t := NewTTML()
+	t.Lang = lang
+	for _, c := range cues {
+		t.AppendCue(c)
+	}
+	_, err := io.WriteString(w, t.String())
+	return err
+}
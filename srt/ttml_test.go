@@ -0,0 +1,45 @@
+package srt
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeTTML(t *testing.T) {
+	cues := []Cue{{Start: 61500 * time.Millisecond, End: 63 * time.Second, Lines: []string{"Tom & Jerry", "<b>"}}}
+	var b strings.Builder
+	if err := EncodeTTML(&b, cues, "ja"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if !strings.Contains(out, `<p begin="00:01:01.500" end="00:01:03.000">Tom &amp; Jerry<br/>&lt;b&gt;</p>`) {
+		t.Errorf("unexpected paragraph in\n%s", out)
+	}
+	if !strings.Contains(out, `xml:lang="ja"`) {
+		t.Errorf("missing language in\n%s", out)
+	}
+	// must be well-formed
+	d := xml.NewDecoder(strings.NewReader(out))
+	for {
+		if _, err := d.Token(); err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+}
+
+func TestEncodeSBV(t *testing.T) {
+	cues := []Cue{{Start: 3661500 * time.Millisecond, End: 3663 * time.Second, Lines: []string{"a", "b"}}}
+	var b strings.Builder
+	if err := EncodeSBV(&b, cues); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1:01:01.500,1:01:03.000\na\nb\n\n"; b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
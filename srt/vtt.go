@@ -0,0 +1,161 @@
+package srt
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	vttTagRe   = regexp.MustCompile(`<[^>]*>`)
+
+	errVTTHeader = errors.New("missing WEBVTT header")
+)
+
+// VTT builds a WebVTT file
+type VTT struct {
+	builder strings.Builder
+	notes   []string
+	// Settings are the default cue settings, e.g. "line:85% align:center",
+	// used when the cue doesn't carry its own.
+	Settings string
+}
+
+// formatVTTTime converts the duration to WebVTT Time
+// for example 137.99s -> 00:02:17.990
+func formatVTTTime(d time.Duration) string {
+	hour, minute, second, milli := splitTime(d)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hour, minute, second, milli)
+}
+
+func NewVTT() *VTT {
+	return &VTT{}
+}
+
+// AddNote adds a NOTE block after the header, e.g. the engine and the language
+func (v *VTT) AddNote(note string) {
+	// "-->" is not allowed inside NOTE blocks
+	v.notes = append(v.notes, strings.ReplaceAll(note, "-->", "->"))
+}
+
+// Append appends a cue with the given content, an empty or inverted
+// time range is skipped.
+func (v *VTT) Append(start, end time.Duration, content string) {
+	v.appendCue(start, end, content, v.Settings)
+}
+
+// AppendCue appends the cue with its own settings if any
+func (v *VTT) AppendCue(c Cue) {
+	settings := c.Settings
+	if settings == "" {
+		settings = v.Settings
+	}
+	v.appendCue(c.Start, c.End, c.Text(), settings)
+}
+
+func (v *VTT) appendCue(start, end time.Duration, content, settings string) {
+	if end <= start {
+		return
+	}
+	v.builder.WriteString(formatVTTTime(start) + " --> " + formatVTTTime(end))
+	if settings != "" {
+		v.builder.WriteString(" " + settings)
+	}
+	v.builder.WriteString("\n")
+	v.builder.WriteString(vttEscaper.Replace(content))
+	v.builder.WriteString("\n")
+	v.builder.WriteString("\n")
+}
+
+func (v *VTT) String() string {
+	var header strings.Builder
+	header.WriteString("WEBVTT\n\n")
+	for _, note := range v.notes {
+		header.WriteString("NOTE\n")
+		header.WriteString(note)
+		header.WriteString("\n\n")
+	}
+	return header.String() + v.builder.String()
+}
+
+func (v *VTT) Reset() {
+	v.builder.Reset()
+	v.notes = nil
+}
+
+// EncodeVTT writes the cues as a WebVTT file
+func EncodeVTT(w io.Writer, cues []Cue, notes ...string) error {
+	v := NewVTT()
+	for _, note := range notes {
+		v.AddNote(note)
+	}
+	for _, c := range cues {
+		v.AppendCue(c)
+	}
+	_, err := io.WriteString(w, v.String())
+	return err
+}
+
+// ParseVTT reads a WebVTT file into cues. The header, NOTE, STYLE and REGION blocks
+// and the cue identifiers are skipped, the cue settings are kept, the markup tags
+// like <i> are removed and the character references are unescaped.
+func ParseVTT(r io.Reader) ([]Cue, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "WEBVTT") {
+		text := ""
+		if len(lines) > 0 {
+			text = lines[0]
+		}
+		return nil, &ParseError{Line: 1, Text: text, Err: errVTTHeader}
+	}
+
+	var cues []Cue
+	// The header block ends at the first blank line
+	i := 1
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		i++
+	}
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		// Every block ends at the next blank line
+		begin := i
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+		}
+		block := lines[begin : i+1]
+
+		first := strings.TrimSpace(block[0])
+		if first == "NOTE" || strings.HasPrefix(first, "NOTE ") || first == "STYLE" || first == "REGION" {
+			continue
+		}
+		line := begin
+		if !isTiming(block[0]) {
+			// Cue identifier
+			if len(block) < 2 || !isTiming(block[1]) {
+				return nil, &ParseError{Line: begin + 1, Text: block[0], Err: errTiming}
+			}
+			block = block[1:]
+			line++
+		}
+		start, end, settings, err := parseTiming(block[0])
+		if err != nil {
+			return nil, &ParseError{Line: line + 1, Text: block[0], Err: err}
+		}
+		cue := Cue{Index: len(cues) + 1, Start: start, End: end, Settings: settings}
+		for _, text := range block[1:] {
+			cue.Lines = append(cue.Lines, html.UnescapeString(vttTagRe.ReplaceAllString(text, "")))
+		}
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
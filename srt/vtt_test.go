@@ -0,0 +1,63 @@
+package srt
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeVTT(t *testing.T) {
+	cues := []Cue{
+		{Start: 61500 * time.Millisecond, End: 63 * time.Second, Lines: []string{"Tom & <Jerry>"}},
+		{Start: 64 * time.Second, End: 65 * time.Second, Lines: []string{"top"}, Settings: "line:0"},
+	}
+	var b strings.Builder
+	if err := EncodeVTT(&b, cues, "engine: google speech\nlanguage: ja"); err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\nNOTE\nengine: google speech\nlanguage: ja\n\n" +
+		"00:01:01.500 --> 00:01:03.000\nTom &amp; &lt;Jerry&gt;\n\n" +
+		"00:01:04.000 --> 00:01:05.000 line:0\ntop\n\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestParseVTT(t *testing.T) {
+	input := "\ufeffWEBVTT - with header text\r\nKind: captions\r\n\r\n" +
+		"NOTE\nengine: google speech\nlanguage: ja\n\n" +
+		"STYLE\n::cue { color: yellow }\n\n" +
+		"intro\n00:01.000 --> 00:02.500 line:85% align:center\n<i>こんにちは</i>\nTom &amp; Jerry\n\n" +
+		"00:00:03.000 --> 00:00:04.000\nsecond\n"
+	cues, err := ParseVTT(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Index: 1, Start: time.Second, End: 2500 * time.Millisecond, Lines: []string{"こんにちは", "Tom & Jerry"}, Settings: "line:85% align:center"},
+		{Index: 2, Start: 3 * time.Second, End: 4 * time.Second, Lines: []string{"second"}},
+	}
+	if !reflect.DeepEqual(cues, want) {
+		t.Errorf("got %+v\nwant %+v", cues, want)
+	}
+
+	// the cues survive the round trip
+	var b strings.Builder
+	if err := EncodeVTT(&b, cues); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseVTT(strings.NewReader(b.String()))
+	if err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("round trip: %+v %v", again, err)
+	}
+
+	var perr *ParseError
+	if _, err := ParseVTT(strings.NewReader("1\n00:00:01,000 --> 00:00:02,000\nsrt\n")); !errors.As(err, &perr) || perr.Line != 1 {
+		t.Errorf("missing header: %v", err)
+	}
+	if _, err := ParseVTT(strings.NewReader("WEBVTT\n\nid\nnot a timing\n")); !errors.As(err, &perr) || perr.Line != 3 {
+		t.Errorf("bad block: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package srt
+
+import "fmt"
+
+// Format is the subtitle file format
+type Format string
+
+const (
+	FormatSRT  Format = "srt"
+	FormatVTT  Format = "vtt"
+	FormatASS  Format = "ass"
+	FormatTTML Format = "ttml"
+	FormatDFXP Format = "dfxp" // TTML with the legacy extension
+	FormatSBV  Format = "sbv"
+)
+
+// Writer is implemented by all subtitle formats
+type Writer interface {
+	AppendCue(c Cue)
+	String() string
+	Reset()
+}
+
+// Noter is implemented by the formats which can carry metadata comments
+type Noter interface {
+	AddNote(note string)
+}
+
+// NewWriter returns the writer of the format
+func NewWriter(f Format) (Writer, error) {
+	switch f {
+	case FormatSRT:
+		return New(), nil
+	case FormatVTT:
+		return NewVTT(), nil
+	case FormatASS:
+		return NewASS(), nil
+	case FormatTTML, FormatDFXP:
+		return NewTTML(), nil
+	case FormatSBV:
+		return NewSBV(), nil
+	}
+	return nil, fmt.Errorf("unknown subtitle format: %s", f)
+}
+
+// Ext returns the file extension of the format
+func (f Format) Ext() string {
+	return "." + string(f)
+}
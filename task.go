@@ -2,48 +2,54 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/MeteorsLiu/goSRT/srt"
+	"github.com/MeteorsLiu/goSRT/cache"
 	"github.com/MeteorsLiu/goSRT/transcribe"
+	"github.com/MeteorsLiu/goSRT/translate"
 	"github.com/MeteorsLiu/goSRT/voice"
-	gt "github.com/bas24/googletranslatefree"
-	"github.com/jellyqwq/Paimon/webapi"
 	"github.com/schollz/progressbar/v3"
 	"golang.org/x/sync/semaphore"
 )
 
 var (
 	t *transcribe.Transcriber
-	s = sync.Pool{
-		New: func() any {
-			return new(srt.SRT)
-		},
-	}
 )
 
+// Subtitle 保存一个区域的原文和译文
 type Subtitle struct {
 	voice.Region
-	Subtitle_String string
+	ID          int    // 区域序号
+	Source      string // 识别出的原文
+	Translation string // 译文，未翻译或翻译失败时为空
+
+	Confidence float64 // 识别引擎给出的置信度
+	SpeechProb float64 // 区域内平均人声概率，只在输出JSON时计算
+	Engine     string  // 识别引擎
+	Attempts   int     // 识别请求次数
+	Err        string  // 识别失败的原因
+
+	TranslationEngine string // 翻译成功的引擎，全部失败时为最后尝试的引擎
+	TranslationErr    string // 翻译失败的原因
 }
 
-func srtNameOf(filename, vadMode string) string {
+func subtitleNameOf(filename, vadMode, ext string) string {
 	fn := filepath.Base(filename)
 	dir := filepath.Dir(filename)
 	prefix := strings.Split(fn, ".")[0]
 
-	srtname := filepath.Join(dir, prefix+".srt")
+	srtname := filepath.Join(dir, prefix+ext)
 
 	if _, err := os.Stat(srtname); err == nil {
-		tempFile, err := os.CreateTemp(dir, fmt.Sprintf("%s_%s_*.srt", prefix, vadMode))
+		tempFile, err := os.CreateTemp(dir, fmt.Sprintf("%s_%s_*%s", prefix, vadMode, ext))
 		if err != nil {
 			panic(err)
 		}
@@ -55,9 +61,103 @@ func srtNameOf(filename, vadMode string) string {
 	return srtname
 }
 
-func DoVad(numConcurrent int, needTranslate bool, lang, filename, vadMode string) {
-	isChina := transcribe.IsChina()
-	t = transcribe.New(lang)
+// chainName 返回翻译引擎链的名字，例如 "openai -> google"
+func chainName(chain []translate.Translator) string {
+	names := make([]string, len(chain))
+	for i, tr := range chain {
+		names[i] = tr.Name()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// translateAll 按时间顺序批量翻译，被切开的句子可以和前后的字幕一起翻译，
+// 失败的字幕依次交给后备引擎，最后汇总没有翻译的字幕，返回没有翻译的条数
+func translateAll(subs []Subtitle, stage translate.Stage) int {
+	log.Printf("Start to translate to %s with %s", stage.Target, chainName(append([]translate.Translator{stage.Translator}, stage.Fallback...)))
+
+	texts := make([]string, len(subs))
+	for i, sub := range subs {
+		texts[i] = sub.Source
+	}
+	var untranslated []string
+	for i, ret := range stage.Run(texts) {
+		if texts[i] == "" {
+			continue
+		}
+		subs[i].TranslationEngine = ret.Engine
+		if ret.Err != nil {
+			subs[i].TranslationErr = ret.Err.Error()
+			untranslated = append(untranslated, fmt.Sprintf("%s %s", timestampOf(subs[i].Start), ret.Err))
+			continue
+		}
+		subs[i].Translation = ret.Text
+	}
+	if len(untranslated) > 0 {
+		log.Printf("%d of %d cues are not translated, the source text is kept:\n%s", len(untranslated), len(subs), strings.Join(untranslated, "\n"))
+	}
+	return len(untranslated)
+}
+
+// transcribeCached 识别切片，相同的切片内容、引擎和语言直接使用缓存的结果，不再上传
+func transcribeCached(store *cache.Cache, file, lang string) (transcribe.Result, error) {
+	if store == nil {
+		return t.TranscribeResult(file)
+	}
+	pcm, err := os.ReadFile(file)
+	if err != nil {
+		return t.TranscribeResult(file)
+	}
+	key := cache.Key("asr", pcm, t.Engine(), lang)
+	if value, ok := store.Get(key); ok {
+		var ret transcribe.Result
+		if json.Unmarshal([]byte(value), &ret) == nil {
+			os.Remove(file)
+			// 没有发送请求
+			ret.Attempts = 0
+			return ret, nil
+		}
+	}
+
+	ret, err := t.TranscribeResult(file)
+	if err == nil {
+		if value, err := json.Marshal(ret); err == nil {
+			store.Put(key, string(value))
+		}
+	}
+	return ret, err
+}
+
+// estimateRequests 估算上传请求数量：每个切片至少一次识别请求，失败最多重试RETRY_TIMES次；
+// 翻译每batch条字幕一次请求，批量翻译结果对不上时逐条重新翻译
+func estimateRequests(slices int, needTranslate bool, batch int) (least, most int) {
+	least = slices
+	most = slices * (1 + transcribe.RETRY_TIMES)
+	if needTranslate {
+		batch = max(batch, 1)
+		batches := (slices + batch - 1) / batch
+		least += batches
+		most += slices
+		if batch > 1 {
+			most += batches
+		}
+	}
+	return
+}
+
+func DoVad(numConcurrent int, needTranslate bool, lang, filename, vadMode string, audio voice.Options, tc translate.Config, store *cache.Cache, resume, dryRun bool, out Output) {
+	if _, err := out.writerOf(lang); err != nil {
+		log.Fatal(err)
+	}
+	if needTranslate {
+		if err := translate.CheckChain(tc.Engine); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !needTranslate {
+		out.Mode = ModeSource
+	}
+	// JSON需要逐帧人声概率作为区域的置信度参考
+	audio.SpeechProb = out.Format == FormatJSON
 
 	// 选择VAD模式
 	mode := voice.VadModeWebRTC
@@ -71,39 +171,110 @@ func DoVad(numConcurrent int, needTranslate bool, lang, filename, vadMode string
 		log.Println("Using WebRTC VAD (default)")
 	}
 
-	v, err := voice.NewWithMode(filename, mode)
+	audio.Mode = mode
+	v, err := voice.NewWithOptions(filename, audio)
 	if err != nil {
 		log.Fatal(err)
 	}
-	subrip := s.Get().(*srt.SRT)
-	subrip.Reset()
+	if info := v.Info(); info.Duration > 0 {
+		est := voice.EstimateSlices(info.Duration)
+		least, most := estimateRequests(est, needTranslate, tc.Batch)
+		log.Printf("Estimated at least %d slices if fully voiced, %d-%d requests", est, least, most)
+	}
 	defer func() {
 		v.Close()
 		v = nil
-		s.Put(subrip)
 	}()
 	var wg sync.WaitGroup
 	var lock sync.Mutex
 	sema := semaphore.NewWeighted(int64(numConcurrent))
 
 	trans := map[int]Subtitle{}
-	regions := v.Vad()
+	var cp *checkpoint
+	var regions []voice.Region
+	if resume {
+		// 使用进度文件中的人声区域，保证区域序号和上次一致
+		cp, err = loadCheckpoint(filename, lang, vadMode, audio)
+		if err != nil {
+			log.Fatal(err)
+		}
+		regions = cp.Regions
+		for id, sub := range cp.Done {
+			trans[id] = sub
+		}
+		log.Printf("Resume from %s: %d of %d slices done", cp.path, len(cp.Done), len(regions))
+		if audio.SpeechProb {
+			// 人声概率没有保存在进度文件中，重新做一次VAD，区域仍然使用进度文件中的
+			v.Vad()
+		}
+	} else {
+		regions = v.Vad()
+	}
 	if len(regions) == 0 || regions == nil {
 		log.Println("unknown regions " + filename)
 		return
 	}
+	least, most := estimateRequests(len(regions), needTranslate, tc.Batch)
+	log.Printf("VAD detected %d slices, %d-%d requests", len(regions), least, most)
+	if dryRun {
+		return
+	}
+	if cp == nil {
+		if _, err := os.Stat(checkpointNameOf(filename)); err == nil {
+			log.Println("Overwrite the checkpoint of the last job, use -resume to continue it")
+		}
+		cp = newCheckpoint(filename, lang, vadMode, audio, regions)
+		// 人声区域识别比较耗时，先保存下来
+		if err := cp.flush(); err != nil {
+			log.Println("Write checkpoint failed:", err)
+		}
+	}
+	t = transcribe.New(lang)
+	out.Engine = t.Engine()
+	var chain []translate.Translator
+	if needTranslate {
+		// transcribe.New已经检测过网络环境，不再重复请求
+		tc.IsChina = t.IsChina()
+		chain, err = translate.NewChain(tc)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out.TranslationEngine = chainName(chain)
+	}
+
 	log.Println("Start to transcribe the video")
 
-	slices := v.To(regions)
+	// 只处理没有完成的切片
+	todo := cp.missing()
+	pending := make([]voice.Region, len(todo))
+	for i, id := range todo {
+		pending[i] = regions[id]
+	}
+	slices := v.To(pending)
 	log.Println("Slices Done")
 	log.Println("Start to upload the video slices")
 	bar := progressbar.Default(int64(len(slices)))
+	stopFlush := cp.autoFlush()
 
+	// slices和pending一一对应，切片失败的区域为空字符串
 	for index, file := range slices {
+		id := todo[index]
+		if file == "" {
+			// 不写入进度文件，-resume时重新处理
+			lock.Lock()
+			trans[id] = Subtitle{
+				Region:     regions[id],
+				ID:         id,
+				SpeechProb: v.SpeechProbability().Mean(regions[id]),
+				Err:        "failed to extract the slice",
+			}
+			lock.Unlock()
+			bar.Add(1)
+			continue
+		}
+
 		sema.Acquire(context.TODO(), 1)
 		wg.Add(1)
-
-		id := index
 		file := file
 
 		go func() {
@@ -111,36 +282,35 @@ func DoVad(numConcurrent int, needTranslate bool, lang, filename, vadMode string
 			defer sema.Release(1)
 			defer bar.Add(1)
 
-			subtitle, err := t.Transcribe(file, true)
+			ret, err := transcribeCached(store, file, lang)
+			sub := Subtitle{
+				Region:     regions[id],
+				ID:         id,
+				Confidence: ret.Confidence,
+				SpeechProb: v.SpeechProbability().Mean(regions[id]),
+				Engine:     ret.Engine,
+				Attempts:   ret.Attempts,
+			}
 
 			if err != nil {
 				if !errors.Is(err, transcribe.MAYBE_RETRY) {
 					log.Printf("ID: %d error occurs: %v", id, err)
 				}
+				sub.Err = err.Error()
+				lock.Lock()
+				trans[id] = sub
+				lock.Unlock()
 				return
 			}
-
-			if needTranslate {
-				var ts string
-				if isChina {
-					ts, err = webapi.RranslateByYouDao(subtitle)
-				} else {
-					ts, err = gt.Translate(subtitle, "auto", "zh-CN")
-				}
-				if err == nil {
-					subtitle = ts
-				}
-			}
-
+			sub.Source = ret.Text
 			lock.Lock()
-			trans[id] = Subtitle{
-				Region:          regions[id],
-				Subtitle_String: subtitle,
-			}
+			trans[id] = sub
 			lock.Unlock()
+			cp.add(sub)
 		}()
 	}
 	wg.Wait()
+	stopFlush()
 
 	log.Println("Transcribe Done.Waiting to sort the subtitle")
 
@@ -149,13 +319,41 @@ func DoVad(numConcurrent int, needTranslate bool, lang, filename, vadMode string
 		keys = append(keys, k)
 	}
 	sort.Ints(keys)
+	subs := make([]Subtitle, 0, len(keys))
 	for _, k := range keys {
-		start := strconv.FormatFloat(trans[k].Region.Start, 'f', -1, 64)
-		end := strconv.FormatFloat(trans[k].Region.End, 'f', -1, 64)
-		log.Println(start, end)
-		subrip.Append(start, end, trans[k].Subtitle_String)
+		subs = append(subs, trans[k])
+	}
+
+	if len(chain) > 0 {
+		translateAll(subs, translate.Stage{
+			Translator:  chain[0],
+			Fallback:    chain[1:],
+			Source:      lang,
+			Target:      tc.Target,
+			Batch:       tc.Batch,
+			Context:     tc.Context,
+			Concurrency: numConcurrent,
+			Glossary:    tc.Glossary,
+			Cache:       store,
+		})
 	}
-	if err := os.WriteFile(srtNameOf(filename, vadMode), []byte(subrip.String()), 0755); err != nil {
+	if store != nil {
+		log.Printf("Cache: %d hits, %d entries", store.Hits(), store.Len())
+	}
+	if err := out.write(filename, vadMode, lang, tc.Target, subs); err != nil {
 		log.Printf("Generating Subrip File Failed: %v", err)
+		return
+	}
+	failed := 0
+	for _, sub := range subs {
+		if sub.Err != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		// 保留进度文件，-resume时只重新处理失败的切片
+		log.Printf("%d of %d slices failed, run again with -resume to retry them", failed, len(subs))
+		return
 	}
+	cp.remove()
 }
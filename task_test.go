@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MeteorsLiu/goSRT/transcribe"
+)
+
+func TestEstimateRequests(t *testing.T) {
+	retry := transcribe.RETRY_TIMES
+	for _, c := range []struct {
+		slices        int
+		needTranslate bool
+		batch         int
+		least, most   int
+	}{
+		{0, true, 1, 0, 0},
+		{10, false, 1, 10, 10 * (1 + retry)},
+		// one by one: one translation request per slice
+		{10, true, 1, 20, 10*(1+retry) + 10},
+		{10, true, 0, 20, 10*(1+retry) + 10},
+		// batches of 4: 3 requests, all of them may fall back to one by one
+		{10, true, 4, 13, 10*(1+retry) + 10 + 3},
+	} {
+		least, most := estimateRequests(c.slices, c.needTranslate, c.batch)
+		if least != c.least || most != c.most {
+			t.Errorf("estimateRequests(%d, %v, %d) = %d, %d, want %d, %d", c.slices, c.needTranslate, c.batch, least, most, c.least, c.most)
+		}
+	}
+}
@@ -30,6 +30,16 @@ var (
 type Transcriber struct {
 	bufPool sync.Pool
 	url     string
+	engine  string
+	china   bool
+}
+
+// Result is the recognized text of a slice with its metadata
+type Result struct {
+	Text       string
+	Confidence float64 // 0 if the API doesn't report it
+	Attempts   int     // how many requests are sent
+	Engine     string
 }
 
 func GetLangCode() map[string]string {
@@ -194,10 +204,13 @@ func New(lang string) *Transcriber {
 		log.Fatal("error language code")
 	}
 	var url string
+	engine := "google"
 
-	if IsChina() {
+	china := IsChina()
+	if china {
 		log.Println("use Google Speech China API")
 		url = fmt.Sprintf(GOOGLE_CN_URL, lang, KEY)
+		engine = "google-cn"
 	} else {
 		url = fmt.Sprintf(GOOGLE_COMMON_URL, lang, KEY)
 	}
@@ -208,19 +221,32 @@ func New(lang string) *Transcriber {
 				return new(bytes.Buffer)
 			},
 		},
-		url: url,
+		url:    url,
+		engine: engine,
+		china:  china,
 	}
 }
 
+// Engine returns the name of the recognition engine
+func (t *Transcriber) Engine() string {
+	return t.engine
+}
+
+// IsChina reports whether New detected a network in China
+func (t *Transcriber) IsChina() bool {
+	return t.china
+}
+
 type googleResponse struct {
 	Result []struct {
 		Alternative []struct {
-			Transcript string `json:"transcript"`
+			Transcript string  `json:"transcript"`
+			Confidence float64 `json:"confidence"`
 		} `json:"alternative"`
 	} `json:"result"`
 }
 
-func (t *Transcriber) transcribe(fileName string) (string, error) {
+func (t *Transcriber) transcribe(fileName string) (Result, error) {
 	defer func() {
 		// Don't let it panic
 		_ = recover()
@@ -230,13 +256,13 @@ func (t *Transcriber) transcribe(fileName string) (string, error) {
 
 	file, err := os.Open(fileName)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 	defer file.Close()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", t.url, file)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 	req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/102.0.0.0 Safari/537.36")
 
@@ -249,7 +275,7 @@ func (t *Transcriber) transcribe(fileName string) (string, error) {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", MAYBE_RETRY
+		return Result{}, MAYBE_RETRY
 	}
 	defer resp.Body.Close()
 
@@ -262,45 +288,57 @@ func (t *Transcriber) transcribe(fileName string) (string, error) {
 		//log.Println(ret)
 		for _, res := range ret.Result {
 			if len(res.Alternative) > 0 && res.Alternative[0].Transcript != "" {
-				return res.Alternative[0].Transcript, nil
+				return Result{
+					Text:       res.Alternative[0].Transcript,
+					Confidence: res.Alternative[0].Confidence,
+					Engine:     t.engine,
+				}, nil
 			}
 		}
 	}
-	return "", MAYBE_RETRY
+	return Result{}, MAYBE_RETRY
 }
 
-func doRetry(call func() (string, error)) (string, error) {
+func doRetry(call func() (Result, error)) (Result, error) {
 	var err error
-	var ret string
+	var ret Result
 	for i := 0; i < RETRY_TIMES; i++ {
 		ret, err = call()
+		ret.Attempts = i + 1
 		if err == nil {
 			return ret, nil
 		}
 	}
 	return ret, err
 }
+
 func (t *Transcriber) Transcribe(file string, isVad bool) (string, error) {
+	ret, err := t.TranscribeResult(file)
+	return ret.Text, err
+}
+
+// TranscribeResult works like Transcribe but also reports the confidence and the attempts
+func (t *Transcriber) TranscribeResult(file string) (Result, error) {
 	if file == "" {
-		return "", errors.New("nil pointer")
+		return Result{}, errors.New("nil pointer")
 	}
 	defer os.Remove(file)
-	var ret string
 	ret, err := t.transcribe(file)
+	ret.Attempts = 1
 	if err != nil {
 		if errors.Is(err, MAYBE_RETRY) {
-			ret, err = doRetry(func() (string, error) {
+			ret, err = doRetry(func() (Result, error) {
 				return t.transcribe(file)
 			})
+			ret.Attempts++
 		}
 		if err != nil {
-			return "", err
+			return Result{Attempts: ret.Attempts, Engine: t.engine}, err
 		}
 	}
 	// Maybe it panic
-	if ret == "" {
-		return "", errors.New("transcribe panic")
+	if ret.Text == "" {
+		return Result{Attempts: ret.Attempts, Engine: t.engine}, errors.New("transcribe panic")
 	}
 	return ret, nil
-
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MeteorsLiu/goSRT/cache"
+	"github.com/MeteorsLiu/goSRT/srt"
+	"github.com/MeteorsLiu/goSRT/transcribe"
+	"github.com/MeteorsLiu/goSRT/translate"
+	"github.com/MeteorsLiu/goSRT/voice"
+)
+
+// runTranslate 处理 translate 子命令，翻译已有的SRT/WebVTT字幕，不需要重新识别
+func runTranslate(args []string) error {
+	fs := flag.NewFlagSet("translate", flag.ExitOnError)
+	lang := fs.String("lang", "", "Source language, empty for auto detection (原文语言)")
+	tf := addTranslateFlags(fs)
+	// 默认不改变原有的时间轴，只修正重叠
+	lf := addLayoutFlags(fs, srt.Timing{})
+	concurrency := fs.Int("concurrency", 10, "Concurrent translation requests (翻译并发数量)")
+	mode := fs.String("output", string(ModeTranslation), "translation or stacked (bilingual) (只输出译文或双语)")
+	cachePath := fs.String("cache", cache.DefaultPath(), "Cache file of the translation results, empty to disable (翻译结果缓存文件)")
+	output := fs.String("o", "", "Output file, the format follows the extension, default xxx.<to>.srt or xxx.<to>.vtt following the input (输出文件)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gotranscriber translate [options] file.srt|file.vtt")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("missing subtitle file")
+	}
+	input := fs.Arg(0)
+
+	switch SubtitleMode(*mode) {
+	case ModeTranslation, ModeStacked:
+	default:
+		return fmt.Errorf("unknown subtitle mode: %s, use translation or stacked", *mode)
+	}
+	if *output == "" {
+		*output = derivedNameOf(input, tf.to)
+	}
+	// 翻译前检查输出格式，避免翻译完才发现无法写入
+	w, err := srt.NewWriter(srt.Format(strings.TrimPrefix(filepath.Ext(*output), ".")))
+	if err != nil {
+		return err
+	}
+
+	cues, err := readCues(input)
+	if err != nil {
+		return err
+	}
+	tc, err := tf.config()
+	if err != nil {
+		return err
+	}
+	if translate.HasEngine(tc.Engine, "auto") {
+		tc.IsChina = transcribe.IsChina()
+	}
+	chain, err := translate.NewChain(tc)
+	if err != nil {
+		return err
+	}
+
+	var store *cache.Cache
+	if *cachePath != "" {
+		if store, err = cache.Open(*cachePath); err != nil {
+			return err
+		}
+		defer store.Close()
+	}
+
+	subs := make([]Subtitle, len(cues))
+	for i, cue := range cues {
+		subs[i] = Subtitle{
+			Region: voice.Region{Start: cue.Start.Seconds(), End: cue.End.Seconds()},
+			ID:     i,
+			Source: cue.Text(),
+		}
+	}
+	untranslated := translateAll(subs, translate.Stage{
+		Translator:  chain[0],
+		Fallback:    chain[1:],
+		Source:      *lang,
+		Target:      tc.Target,
+		Batch:       tc.Batch,
+		Context:     tc.Context,
+		Concurrency: *concurrency,
+		Glossary:    tc.Glossary,
+		Cache:       store,
+	})
+
+	// 保留原有的cue settings，只替换文本后重新排版
+	o := Output{Layout: lf.layout(), Timing: lf.timing(), TranslationEngine: chainName(chain)}
+	for i := range cues {
+		text := subs[i].translated()
+		if SubtitleMode(*mode) == ModeStacked && text != subs[i].Source {
+			// 双语字幕只折行不拆分，避免原文和译文错开
+			text = o.wrap(subs[i].Source) + "\n" + o.wrap(text)
+		}
+		cues[i].Lines = strings.Split(text, "\n")
+	}
+	if SubtitleMode(*mode) == ModeStacked {
+		cues = o.Timing.Normalize(cues)
+	} else {
+		cues = o.Timing.Normalize(o.Layout.Apply(cues))
+	}
+	langs := []string{tc.Target}
+	if SubtitleMode(*mode) == ModeStacked && *lang != "" {
+		langs = []string{*lang, tc.Target}
+	}
+	o.noteEngines(w, true, langs...)
+	noteUntranslated(w, subs)
+	for _, cue := range cues {
+		w.AppendCue(cue)
+	}
+	if err := os.WriteFile(*output, []byte(w.String()), 0755); err != nil {
+		return err
+	}
+	fmt.Printf("Translated %d of %d cues to %s\n", len(subs)-untranslated, len(subs), *output)
+	return nil
+}
@@ -0,0 +1,212 @@
+package translate
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// ErrGlossaryMismatch is returned when the engine dropped, duplicated or changed the placeholders
+	ErrGlossaryMismatch = errors.New("the placeholders of the glossary terms are changed by the engine")
+
+	// placeholderRe matches the placeholders after translation,
+	// the engines may add spaces or turn the brackets into full-width ones.
+	placeholderRe = regexp.MustCompile(`[\[［【]\s*[\[［【]\s*(\d+)\s*[\]］】]\s*[\]］】]`)
+)
+
+// Glossary keeps the terms consistent across the translation requests.
+// Before translation every term is masked with a placeholder like [[0]],
+// which is restored to the target term (or the term itself if it's protected) after.
+type Glossary struct {
+	terms   []string // sorted by length, the longest is matched first
+	targets []string
+	index   map[string]int
+	re      *regexp.Regexp
+}
+
+// NewGlossary creates the glossary, terms maps the source term to the target term,
+// protected are the names which shouldn't be translated.
+func NewGlossary(terms map[string]string, protected []string) *Glossary {
+	all := map[string]string{}
+	for _, p := range protected {
+		if p = strings.TrimSpace(p); p != "" {
+			all[p] = p
+		}
+	}
+	for term, target := range terms {
+		if term = strings.TrimSpace(term); term != "" {
+			all[term] = strings.TrimSpace(target)
+		}
+	}
+
+	g := &Glossary{}
+	for term := range all {
+		g.terms = append(g.terms, term)
+	}
+	sort.Slice(g.terms, func(i, j int) bool {
+		if len(g.terms[i]) != len(g.terms[j]) {
+			return len(g.terms[i]) > len(g.terms[j])
+		}
+		return g.terms[i] < g.terms[j]
+	})
+	if len(g.terms) == 0 {
+		return g
+	}
+
+	g.index = make(map[string]int, len(g.terms))
+	patterns := make([]string, len(g.terms))
+	for i, term := range g.terms {
+		g.targets = append(g.targets, all[term])
+		g.index[term] = i
+		pattern := regexp.QuoteMeta(term)
+		// Latin terms only match whole words, Ann shouldn't match Announce
+		if first, _ := utf8.DecodeRuneInString(term); isWordRune(first) {
+			pattern = `\b` + pattern
+		}
+		if last, _ := utf8.DecodeLastRuneInString(term); isWordRune(last) {
+			pattern += `\b`
+		}
+		patterns[i] = pattern
+	}
+	// Go regexp prefers the leftmost alternative, so the longest term wins
+	g.re = regexp.MustCompile(strings.Join(patterns, "|"))
+	return g
+}
+
+// isWordRune reports whether \b works on r, which is only ASCII in Go regexp
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// Len returns the number of terms
+func (g *Glossary) Len() int {
+	if g == nil {
+		return 0
+	}
+	return len(g.terms)
+}
+
+// Fingerprint identifies the terms, the cached translations are invalid once it changes
+func (g *Glossary) Fingerprint() string {
+	if g.Len() == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for i, term := range g.terms {
+		fmt.Fprintf(h, "%s\t%s\n", term, g.targets[i])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Mask replaces the terms in text with the placeholders, ok reports whether any term is masked.
+// A text already containing something like a placeholder is kept as is,
+// since its placeholders couldn't be told apart from the terms after translation.
+func (g *Glossary) Mask(text string) (masked string, ok bool) {
+	if g.Len() == 0 || placeholderRe.MatchString(text) {
+		return text, false
+	}
+	masked = g.re.ReplaceAllStringFunc(text, func(term string) string {
+		ok = true
+		return "[[" + strconv.Itoa(g.index[term]) + "]]"
+	})
+	return masked, ok
+}
+
+// placeholders counts the placeholders in text by the term index
+func placeholders(text string) map[string]int {
+	count := map[string]int{}
+	for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+		count[strings.TrimLeft(m[1], "0")]++
+	}
+	return count
+}
+
+// Unmask replaces the placeholders in the translated text with the target terms,
+// masked is the text sent to the engine. ErrGlossaryMismatch is returned
+// if the placeholders of the two texts differ, since a term would be lost.
+func (g *Glossary) Unmask(masked, translated string) (string, error) {
+	if g.Len() == 0 {
+		return translated, nil
+	}
+	before, after := placeholders(masked), placeholders(translated)
+	if len(before) != len(after) {
+		return "", ErrGlossaryMismatch
+	}
+	for i, n := range before {
+		if after[i] != n {
+			return "", ErrGlossaryMismatch
+		}
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(translated, -1) {
+		if i, err := strconv.Atoi(m[1]); err != nil || i >= len(g.targets) {
+			return "", ErrGlossaryMismatch
+		}
+	}
+	return placeholderRe.ReplaceAllStringFunc(translated, func(placeholder string) string {
+		i, _ := strconv.Atoi(placeholderRe.FindStringSubmatch(placeholder)[1])
+		return g.targets[i]
+	}), nil
+}
+
+type glossaryJSON struct {
+	Terms     map[string]string `json:"terms"`
+	Protected []string          `json:"protected"`
+}
+
+// ParseGlossary reads a TSV glossary, each line is "source<TAB>target",
+// a line without target is a protected term, lines starting with # are comments.
+func ParseGlossary(r io.Reader) (*Glossary, error) {
+	terms := map[string]string{}
+	var protected []string
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\ufeff"), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("glossary line %d: too many columns: %q", n, line)
+		}
+		if len(fields) == 1 || strings.TrimSpace(fields[1]) == "" {
+			protected = append(protected, fields[0])
+			continue
+		}
+		terms[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewGlossary(terms, protected), nil
+}
+
+// LoadGlossary reads a TSV glossary or a JSON one like
+// {"terms": {"source": "target"}, "protected": ["name"]}
+func LoadGlossary(path string) (*Glossary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var g glossaryJSON
+		if err := json.NewDecoder(f).Decode(&g); err != nil {
+			return nil, fmt.Errorf("glossary %s: %w", path, err)
+		}
+		return NewGlossary(g.Terms, g.Protected), nil
+	}
+	return ParseGlossary(f)
+}
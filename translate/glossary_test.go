@@ -0,0 +1,94 @@
+package translate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlossary(t *testing.T) {
+	g, err := ParseGlossary(strings.NewReader("# names\nアーニャ\tAnya\nロイド・フォージャー\t劳埃德·福杰\nロイド\t劳埃德\nAnn\nWISE\t\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Len() != 5 {
+		t.Fatalf("terms: %d", g.Len())
+	}
+
+	masked, ok := g.Mask("ロイド・フォージャーとロイドとアーニャ、Ann and Announce by WISE")
+	if !ok || strings.Contains(masked, "ロイド") || strings.Contains(masked, "アーニャ") || !strings.Contains(masked, "Announce") || strings.Contains(masked, "WISE") {
+		t.Fatalf("masked: %s", masked)
+	}
+	// the engines may add spaces or use full-width brackets
+	translated := strings.NewReplacer("と", " and ", "[[", "［ ［", "]]", "］］").Replace(masked)
+	if got, err := g.Unmask(masked, translated); err != nil || got != "劳埃德·福杰 and 劳埃德 and Anya、Ann and Announce by WISE" {
+		t.Errorf("unmask: %q, %v", got, err)
+	}
+	// a dropped, duplicated or unknown placeholder loses a term
+	for _, translated := range []string{
+		strings.Replace(masked, "[[", "", 1),
+		masked + " [[1]]",
+		strings.Replace(masked, "[[1]]", "[[9]]", 1),
+	} {
+		if _, err := g.Unmask(masked, translated); err != ErrGlossaryMismatch {
+			t.Errorf("unmask %q: %v", translated, err)
+		}
+	}
+
+	// an out-of-range placeholder the engine made up doesn't panic
+	g = NewGlossary(map[string]string{"loid": "Loid"}, nil)
+	if _, err := g.Unmask("[[0]]", "[[0]] [[5]]"); err != ErrGlossaryMismatch {
+		t.Errorf("unknown placeholder: %v", err)
+	}
+	// a text already containing placeholders isn't masked, so they stay literal
+	for _, text := range []string{"see [[5]] loid", "[[0]] loid"} {
+		if masked, ok := g.Mask(text); ok || masked != text {
+			t.Errorf("mask %q: %q, %v", text, masked, ok)
+		}
+	}
+
+	if _, err := ParseGlossary(strings.NewReader("a\tb\tc\n")); err == nil {
+		t.Error("three columns should fail")
+	}
+	var empty *Glossary
+	if masked, ok := empty.Mask("text"); ok || masked != "text" {
+		t.Error("nil glossary should keep the text")
+	}
+	if text, _ := empty.Unmask("text", "[[0]]"); text != "[[0]]" {
+		t.Error("nil glossary should keep the text")
+	}
+}
+
+// dropPlaceholders removes the placeholders like an engine translating them away
+type dropPlaceholders struct{}
+
+func (dropPlaceholders) Name() string {
+	return "drop"
+}
+
+func (dropPlaceholders) Translate(text, source, target string) (string, error) {
+	return placeholderRe.ReplaceAllString(text, ""), nil
+}
+
+func TestStageGlossary(t *testing.T) {
+	u := &upper{}
+	g := NewGlossary(map[string]string{"loid": "Loid"}, []string{"anya"})
+	results := Stage{Translator: u, Batch: 2, Glossary: g}.Run([]string{"hi loid", "anya is here"})
+	if results[0].Text != "HI Loid" || results[1].Text != "anya IS HERE" {
+		t.Errorf("results: %+v", results)
+	}
+	if strings.Contains(u.requests[0], "loid") {
+		t.Errorf("the term is sent to the engine: %q", u.requests[0])
+	}
+
+	// placeholders in the source are kept literal instead of crashing or becoming terms
+	results = Stage{Translator: u, Glossary: g}.Run([]string{"see [[5]] loid", "[[0]]"})
+	if results[0].Err != nil || results[0].Text != "SEE [[5]] LOID" || results[1].Text != "[[0]]" {
+		t.Errorf("literal placeholders: %+v", results)
+	}
+
+	// the engine dropping a placeholder fails the cue, so it goes to the fallback
+	results = Stage{Translator: dropPlaceholders{}, Fallback: []Translator{u}, Glossary: g}.Run([]string{"hi loid"})
+	if results[0].Text != "HI Loid" || results[0].Engine != u.Name() {
+		t.Errorf("fallback: %+v", results[0])
+	}
+}
@@ -0,0 +1,54 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTP_TIMEOUT is the timeout of a request to the self-hosted engines
+var HTTP_TIMEOUT = 2 * time.Minute
+
+// postJSON posts the request as JSON and decodes the response into ret,
+// a response with a non-2xx status is an error including the body.
+func postJSON(url string, header http.Header, request, ret any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), HTTP_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s: %s", url, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, ret)
+}
+
+// endpoint joins the base URL and the path
+func endpoint(base, path string) string {
+	return strings.TrimSuffix(base, "/") + path
+}
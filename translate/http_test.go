@@ -0,0 +1,85 @@
+package translate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLibreTranslate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req libreRequest
+		if r.URL.Path != "/translate" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		if req.APIKey != "secret" {
+			json.NewEncoder(w).Encode(libreResponse{Error: "invalid API key"})
+			return
+		}
+		json.NewEncoder(w).Encode(libreResponse{TranslatedText: req.Source + ">" + req.Target + ":" + req.Q})
+	}))
+	defer server.Close()
+
+	if _, err := New(Config{Engine: "libretranslate"}); err == nil {
+		t.Error("libretranslate without URL should fail")
+	}
+	tr, err := New(Config{Engine: "libretranslate", URL: server.URL + "/", Key: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := tr.Translate("こんにちは", "ja", "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret != "ja>zh:こんにちは" {
+		t.Errorf("got %q", ret)
+	}
+
+	tr = LibreTranslate{URL: server.URL}
+	if _, err := tr.Translate("hello", "", "de"); err == nil || !strings.Contains(err.Error(), "invalid API key") {
+		t.Errorf("expected the API error, got %v", err)
+	}
+}
+
+func TestOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if r.URL.Path != "/v1/chat/completions" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, `{"error":{"message":"unauthorized"}}`, http.StatusUnauthorized)
+			return
+		}
+		var ret chatResponse
+		ret.Choices = append(ret.Choices, struct {
+			Message chatMessage `json:"message"`
+		}{chatMessage{Role: "assistant", Content: " " + req.Model + "|" + req.Messages[1].Content + "\n"}})
+		json.NewEncoder(w).Encode(ret)
+	}))
+	defer server.Close()
+
+	if _, err := New(Config{Engine: "openai"}); err == nil {
+		t.Error("openai without model should fail")
+	}
+	tr, err := New(Config{Engine: "openai", URL: server.URL + "/v1", Key: "secret", Model: "local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := tr.Translate("hello", "en", "ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret != "local|hello" {
+		t.Errorf("got %q", ret)
+	}
+
+	tr = OpenAI{URL: server.URL + "/v1", Model: "local"}
+	if _, err := tr.Translate("hello", "en", "ja"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401, got %v", err)
+	}
+}
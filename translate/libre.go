@@ -0,0 +1,69 @@
+package translate
+
+import (
+	"errors"
+	"strings"
+)
+
+// LibreTranslate speaks the LibreTranslate API, see https://libretranslate.com/docs
+type LibreTranslate struct {
+	URL string
+	Key string
+}
+
+func (LibreTranslate) Name() string {
+	return "libretranslate"
+}
+
+// libreLang converts a BCP 47 tag to the LibreTranslate code, which has no region
+// except zt for traditional Chinese.
+func libreLang(lang string) string {
+	if lang == "" {
+		return "auto"
+	}
+	if IsChinese(lang) && isTraditional(lang) {
+		return "zt"
+	}
+	primary, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	return strings.ToLower(primary)
+}
+
+type libreRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+func (l LibreTranslate) Translate(text, source, target string) (string, error) {
+	var ret libreResponse
+	err := postJSON(endpoint(l.URL, "/translate"), nil, libreRequest{
+		Q:      text,
+		Source: libreLang(source),
+		Target: libreLang(target),
+		Format: "text",
+		APIKey: l.Key,
+	}, &ret)
+	if err != nil {
+		return "", err
+	}
+	if ret.Error != "" {
+		return "", errors.New("libretranslate: " + ret.Error)
+	}
+	return ret.TranslatedText, nil
+}
+
+func init() {
+	Register("libretranslate", func(cfg Config) (Translator, error) {
+		if cfg.URL == "" {
+			return nil, errors.New("libretranslate needs the URL of the server")
+		}
+		return LibreTranslate{URL: cfg.URL, Key: cfg.Key}, nil
+	})
+}
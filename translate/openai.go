@@ -0,0 +1,84 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// OPENAI_URL is the default base URL of the OpenAI-compatible engine
+	OPENAI_URL = "https://api.openai.com/v1"
+	// OPENAI_PROMPT is the system prompt, %s are the source and the target language
+	OPENAI_PROMPT = "You are a subtitle translator. Translate the subtitle from %s into %s. " +
+		"Keep the line breaks and the " + SEPARATOR + " separator lines unchanged, " +
+		"the text between two separators is one subtitle. Reply with the translation only, without any explanation."
+)
+
+// OpenAI speaks the chat completions API of OpenAI,
+// which is also served by vLLM, Ollama, llama.cpp and others.
+type OpenAI struct {
+	URL   string
+	Key   string
+	Model string
+}
+
+func (OpenAI) Name() string {
+	return "openai"
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (o OpenAI) Translate(text, source, target string) (string, error) {
+	if source == "" {
+		source = "the detected language"
+	}
+	header := http.Header{}
+	if o.Key != "" {
+		header.Set("Authorization", "Bearer "+o.Key)
+	}
+	var ret chatResponse
+	err := postJSON(endpoint(o.URL, "/chat/completions"), header, chatRequest{
+		Model: o.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: fmt.Sprintf(OPENAI_PROMPT, source, target)},
+			{Role: "user", Content: text},
+		},
+	}, &ret)
+	if err != nil {
+		return "", err
+	}
+	if len(ret.Choices) == 0 {
+		return "", errors.New("openai: empty response")
+	}
+	return strings.TrimSpace(ret.Choices[0].Message.Content), nil
+}
+
+func init() {
+	Register("openai", func(cfg Config) (Translator, error) {
+		if cfg.Model == "" {
+			return nil, errors.New("openai needs the model")
+		}
+		url := cfg.URL
+		if url == "" {
+			url = OPENAI_URL
+		}
+		return OpenAI{URL: url, Key: cfg.Key, Model: cfg.Model}, nil
+	})
+}
@@ -0,0 +1,243 @@
+package translate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// SEPARATOR separates the cues of a batch, it's a line which
+// the engines keep untouched in most cases.
+const SEPARATOR = "|||"
+
+var (
+	// ErrBatchMismatch is returned when the translated batch can't be mapped back to the cues
+	ErrBatchMismatch = errors.New("the translated batch doesn't match the cues")
+	// ErrEmptyTranslation is returned when the engine returns nothing for a cue
+	ErrEmptyTranslation = errors.New("the translation is empty")
+
+	separatorRe = regexp.MustCompile(`\s*\|\s*\|\s*\|\s*`)
+)
+
+// Result is the translation of a cue
+type Result struct {
+	Text   string
+	Engine string // the engine which translated the cue, or the last one tried if all failed
+	Err    error
+}
+
+// Stage translates the ordered cues in batches, so that a sentence split
+// into several cues can be translated together with its neighbors.
+type Stage struct {
+	Translator Translator
+	// Fallback are tried in order for the cues the previous engines failed to translate
+	Fallback []Translator
+	Source   string // empty for auto detection
+	Target   string
+	// Batch is the number of cues per request, 0 or 1 translates the cues one by one
+	Batch int
+	// Context is the number of neighboring cues sent before and after the batch,
+	// they are translated for reference only and dropped from the results.
+	Context int
+	// Concurrency is the number of concurrent requests, 0 means 1
+	Concurrency int
+	// Glossary masks the terms before translation and restores them after, nil to disable
+	Glossary *Glossary
+	// Cache saves the translation of every cue, nil to disable
+	Cache Store
+}
+
+// Store caches the translations, *cache.Cache implements it
+type Store interface {
+	Get(key string) (string, bool)
+	Put(key, value string) error
+}
+
+// cacheKey addresses the translation of text by the engine and the settings changing it,
+// in the "kind:hash:params" form of the cache package.
+func (s Stage) cacheKey(text, engine string) string {
+	sum := sha256.Sum256([]byte(text))
+	return "mt:" + hex.EncodeToString(sum[:]) + ":" + strings.Join([]string{engine, s.Source, s.Target, s.Glossary.Fingerprint()}, ":")
+}
+
+// cached looks up the translation of the Translator
+func (s Stage) cached(text string) (string, bool) {
+	if s.Cache == nil {
+		return "", false
+	}
+	return s.Cache.Get(s.cacheKey(text, s.Translator.Name()))
+}
+
+// joinBatch joins the texts with the separator lines
+func joinBatch(texts []string) string {
+	return strings.Join(texts, "\n"+SEPARATOR+"\n")
+}
+
+// splitBatch splits the translated batch, it fails if the number of parts differs.
+// A part may be empty if the engine merged the cue into its neighbors.
+func splitBatch(text string, n int) ([]string, error) {
+	parts := separatorRe.Split(strings.TrimSpace(text), -1)
+	if len(parts) != n {
+		return nil, ErrBatchMismatch
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts, nil
+}
+
+// one translates a single cue
+func (s Stage) one(text string) Result {
+	ts, err := s.Translator.Translate(text, s.Source, s.Target)
+	if err == nil && strings.TrimSpace(ts) == "" {
+		err = ErrEmptyTranslation
+	}
+	return Result{Text: ts, Engine: s.Translator.Name(), Err: err}
+}
+
+// batch translates texts[start:end] with the context around,
+// it falls back to one by one if the result can't be mapped back,
+// and retries the cues whose part is empty alone.
+func (s Stage) batch(texts []string, start, end int, results []Result) {
+	if end-start == 1 && s.Context <= 0 {
+		results[start] = s.one(texts[start])
+		return
+	}
+	before := max(start-s.Context, 0)
+	after := min(end+max(s.Context, 0), len(texts))
+
+	ts, err := s.Translator.Translate(joinBatch(texts[before:after]), s.Source, s.Target)
+	if err == nil {
+		var parts []string
+		if parts, err = splitBatch(ts, after-before); err == nil {
+			for i := start; i < end; i++ {
+				if parts[i-before] == "" {
+					results[i] = s.one(texts[i])
+					continue
+				}
+				results[i] = Result{Text: parts[i-before], Engine: s.Translator.Name()}
+			}
+			return
+		}
+	}
+	for i := start; i < end; i++ {
+		results[i] = s.one(texts[i])
+	}
+}
+
+// Run translates the texts in order, the empty texts are skipped
+// and the results have the same length as the texts.
+// The failed cues are translated again by the fallback engines in order,
+// every engine reuses its own cached translations instead of sending them again.
+func (s Stage) Run(texts []string) []Result {
+	results := make([]Result, len(texts))
+	var todo []int
+	for i, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			todo = append(todo, i)
+		}
+	}
+
+	for _, tr := range append([]Translator{s.Translator}, s.Fallback...) {
+		if len(todo) == 0 {
+			break
+		}
+		stage := s
+		stage.Translator, stage.Fallback = tr, nil
+
+		var missing []int
+		for _, i := range todo {
+			if ts, ok := stage.cached(texts[i]); ok {
+				results[i] = Result{Text: ts, Engine: tr.Name()}
+				continue
+			}
+			missing = append(missing, i)
+		}
+
+		var failed []int
+		translated := stage.run(texts, missing)
+		for _, i := range missing {
+			ret := translated[i]
+			results[i] = ret
+			if ret.Err != nil {
+				failed = append(failed, i)
+				continue
+			}
+			if s.Cache != nil && strings.TrimSpace(ret.Text) != "" {
+				s.Cache.Put(stage.cacheKey(texts[i], tr.Name()), ret.Text)
+			}
+		}
+		todo = failed
+	}
+	return results
+}
+
+// run translates texts[i] for the ordered indices in todo with the Translator only,
+// the results have the same length as the texts. The other texts are not translated
+// but still sent as the context, so the context always comes from the real neighbors.
+func (s Stage) run(texts []string, todo []int) []Result {
+	results := make([]Result, len(texts))
+
+	// empty texts are neither translated nor used as the context
+	var index []int
+	var nonEmpty []string
+	var masked []bool
+	position := map[int]int{} // index in texts -> index in nonEmpty
+	for i, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			text, ok := s.Glossary.Mask(text)
+			position[i] = len(nonEmpty)
+			index = append(index, i)
+			nonEmpty = append(nonEmpty, text)
+			masked = append(masked, ok)
+		}
+	}
+
+	// a batch only joins consecutive cues, a cue in between which
+	// is cached or done by the previous engine starts a new batch
+	size := max(s.Batch, 1)
+	var batches [][2]int
+	for _, i := range todo {
+		p, ok := position[i]
+		if !ok {
+			continue
+		}
+		if n := len(batches); n > 0 && batches[n-1][1] == p && p-batches[n-1][0] < size {
+			batches[n-1][1]++
+			continue
+		}
+		batches = append(batches, [2]int{p, p + 1})
+	}
+
+	translated := make([]Result, len(nonEmpty))
+	sema := semaphore.NewWeighted(int64(max(s.Concurrency, 1)))
+	var wg sync.WaitGroup
+	for _, b := range batches {
+		sema.Acquire(context.TODO(), 1)
+		wg.Add(1)
+		b := b
+		go func() {
+			defer wg.Done()
+			defer sema.Release(1)
+			s.batch(nonEmpty, b[0], b[1], translated)
+		}()
+	}
+	wg.Wait()
+
+	for _, b := range batches {
+		for p := b[0]; p < b[1]; p++ {
+			ret := translated[p]
+			if ret.Err == nil && masked[p] {
+				ret.Text, ret.Err = s.Glossary.Unmask(nonEmpty[p], ret.Text)
+			}
+			results[index[p]] = ret
+		}
+	}
+	return results
+}
@@ -0,0 +1,219 @@
+package translate
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// upper translates by upper-casing, it records the requests
+type upper struct {
+	lock     sync.Mutex
+	requests []string
+	merge    bool // drops the separators like an engine joining the sentences
+}
+
+func (u *upper) Name() string {
+	return "upper"
+}
+
+func (u *upper) Translate(text, source, target string) (string, error) {
+	u.lock.Lock()
+	u.requests = append(u.requests, text)
+	u.lock.Unlock()
+	if u.merge && strings.Contains(text, SEPARATOR) {
+		text = strings.ReplaceAll(text, "\n"+SEPARATOR+"\n", " ")
+	}
+	// engines often eat the spaces around the separator
+	return strings.ReplaceAll(strings.ToUpper(text), "\n"+SEPARATOR+"\n", " ||| "), nil
+}
+
+func TestStage(t *testing.T) {
+	texts := []string{"a", "b", "", "c", "d", "e"}
+	u := &upper{}
+	results := Stage{Translator: u, Batch: 2, Context: 1}.Run(texts)
+	want := []string{"A", "B", "", "C", "D", "E"}
+	for i, ret := range results {
+		if ret.Err != nil || ret.Text != want[i] {
+			t.Errorf("cue %d: %+v, want %s", i, ret, want[i])
+		}
+	}
+	// the empty text is skipped, the batches are [a b]+c, b+[c d]+e, d+[e]
+	if len(u.requests) != 3 {
+		t.Fatalf("requests: %q", u.requests)
+	}
+	for _, req := range []string{"a\n|||\nb\n|||\nc", "b\n|||\nc\n|||\nd\n|||\ne", "d\n|||\ne"} {
+		found := false
+		for _, r := range u.requests {
+			found = found || r == req
+		}
+		if !found {
+			t.Errorf("request %q not sent: %q", req, u.requests)
+		}
+	}
+
+	// mismatched batches fall back to one by one
+	u = &upper{merge: true}
+	results = Stage{Translator: u, Batch: 3, Concurrency: 2}.Run([]string{"a", "b", "c", "d"})
+	for i, ret := range results {
+		if ret.Text != strings.ToUpper(string(rune('a'+i))) {
+			t.Errorf("fallback cue %d: %+v", i, ret)
+		}
+	}
+	// [a b c] fails and falls back to 3 requests, [d] is sent alone
+	if len(u.requests) != 1+3+1 {
+		t.Errorf("fallback requests: %q", u.requests)
+	}
+
+	// failures are reported per cue
+	results = Stage{Translator: fake{}}.Run([]string{"ok", "fail"})
+	if results[0].Text != "[] ok" || results[1].Err == nil {
+		t.Errorf("per cue results: %+v", results)
+	}
+}
+
+// blank translates x to nothing like an engine dropping a cue
+type blank struct{}
+
+func (blank) Name() string {
+	return "blank"
+}
+
+func (blank) Translate(text, source, target string) (string, error) {
+	return strings.ReplaceAll(text, "x", ""), nil
+}
+
+// picky fails on every request containing "fail", even with the context
+type picky struct{}
+
+func (picky) Name() string {
+	return "picky"
+}
+
+func (picky) Translate(text, source, target string) (string, error) {
+	if strings.Contains(text, "fail") {
+		return "", errors.New("picky failure")
+	}
+	return text, nil
+}
+
+func TestStageFallback(t *testing.T) {
+	u := &upper{}
+	// fake fails on "fail", upper translates it
+	results := Stage{Translator: fake{}, Fallback: []Translator{u}, Batch: 1}.Run([]string{"ok", "fail", ""})
+	want := []Result{{Text: "[] ok", Engine: "fake"}, {Text: "FAIL", Engine: "upper"}, {}}
+	for i, ret := range results {
+		if ret != want[i] {
+			t.Errorf("cue %d: %+v, want %+v", i, ret, want[i])
+		}
+	}
+	if len(u.requests) != 1 || u.requests[0] != "fail" {
+		t.Errorf("only the failed cue should be retried: %q", u.requests)
+	}
+
+	// the fallback gets the real neighbors of the failed cue as the context
+	u = &upper{}
+	results = Stage{Translator: picky{}, Fallback: []Translator{u}, Context: 1}.Run([]string{"a", "fail", "b"})
+	if results[1].Text != "FAIL" || len(u.requests) != 1 || u.requests[0] != "a\n|||\nfail\n|||\nb" {
+		t.Errorf("fallback context: %+v, requests %q", results, u.requests)
+	}
+
+	// an empty part of the batch is retried alone, then goes to the fallback
+	u = &upper{}
+	results = Stage{Translator: blank{}, Fallback: []Translator{u}, Batch: 2}.Run([]string{"a", "x"})
+	if results[0].Text != "a" || results[1].Text != "X" || results[1].Engine != "upper" {
+		t.Errorf("empty part: %+v", results)
+	}
+	results = Stage{Translator: blank{}}.Run([]string{"x"})
+	if !errors.Is(results[0].Err, ErrEmptyTranslation) {
+		t.Errorf("empty translation: %+v", results[0])
+	}
+
+	// all engines fail, the last one is reported
+	results = Stage{Translator: fake{}, Fallback: []Translator{fake{}}}.Run([]string{"fail"})
+	if results[0].Err == nil || results[0].Engine != "fake" {
+		t.Errorf("all failed: %+v", results[0])
+	}
+}
+
+// memStore is an in-memory Store
+type memStore map[string]string
+
+func (m memStore) Get(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m memStore) Put(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+func TestStageCache(t *testing.T) {
+	store := memStore{}
+	u := &upper{}
+	stage := Stage{Translator: u, Target: "en", Batch: 2, Cache: store}
+	stage.Run([]string{"a", "b", ""})
+	if len(store) != 2 || len(u.requests) != 1 {
+		t.Fatalf("store %v requests %q", store, u.requests)
+	}
+
+	// only the new cue is sent
+	results := stage.Run([]string{"a", "c", "b"})
+	if len(u.requests) != 2 || u.requests[1] != "c" {
+		t.Errorf("requests: %q", u.requests)
+	}
+	for i, want := range []string{"A", "C", "B"} {
+		if results[i].Text != want || results[i].Engine != "upper" {
+			t.Errorf("cue %d: %+v", i, results[i])
+		}
+	}
+
+	// the cached translation of a fallback engine is only used after the primary fails
+	f := Stage{Translator: fake{}, Target: "en", Cache: store}
+	store[f.cacheKey("ok", "upper")] = "OK"
+	store[f.cacheKey("fail", "upper")] = "FAIL"
+	f.Fallback = []Translator{u}
+	results = f.Run([]string{"ok", "fail"})
+	if results[0].Engine != "fake" || results[1].Text != "FAIL" || results[1].Engine != "upper" || len(u.requests) != 2 {
+		t.Errorf("fallback cache: %+v, requests %q", results, u.requests)
+	}
+
+	// empty translations are not cached
+	store = memStore{}
+	Stage{Translator: blank{}, Cache: store}.Run([]string{"x"})
+	if len(store) != 0 {
+		t.Errorf("empty translation cached: %v", store)
+	}
+
+	// another target or glossary misses the cache
+	stage.Target = "de"
+	stage.Run([]string{"a"})
+	stage.Target, stage.Glossary = "en", NewGlossary(nil, []string{"x"})
+	stage.Run([]string{"a"})
+	if len(u.requests) != 4 {
+		t.Errorf("requests: %q", u.requests)
+	}
+
+	// cues around a cached one are not joined, but it's still sent as their context
+	cached := memStore{}
+	fr := Stage{Translator: u, Target: "fr", Cache: cached}
+	cached[fr.cacheKey("a", "upper")] = "A"
+	cached[fr.cacheKey("b", "upper")] = "B"
+	u.requests = nil
+	Stage{Translator: u, Target: "fr", Batch: 3, Cache: cached}.Run([]string{"x", "a", "y"})
+	Stage{Translator: u, Target: "fr", Batch: 3, Context: 1, Cache: cached}.Run([]string{"z", "b", "w"})
+	for _, req := range []string{"x", "y", "z\n|||\nb", "b\n|||\nw"} {
+		found := false
+		for _, r := range u.requests {
+			found = found || r == req
+		}
+		if !found {
+			t.Errorf("request %q not sent: %q", req, u.requests)
+		}
+	}
+	if len(u.requests) != 4 {
+		t.Errorf("requests: %q", u.requests)
+	}
+}
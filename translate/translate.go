@@ -0,0 +1,202 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	gt "github.com/bas24/googletranslatefree"
+	"github.com/jellyqwq/Paimon/webapi"
+)
+
+var (
+	// ErrUnsupportedLanguage is returned when the engine can't translate into the target language
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+)
+
+// Translator translates text from the source language to the target language,
+// the languages are BCP 47 tags like ja or zh-CN, an empty source means auto detection.
+type Translator interface {
+	Name() string
+	Translate(text, source, target string) (string, error)
+}
+
+// Config is used to create a Translator
+type Config struct {
+	Engine  string // the registered engine name, e.g. google, youdao or auto, comma separated for NewChain
+	Target  string // the target language
+	IsChina bool   // google is unreachable from mainland China
+
+	// self-hosted engines
+	URL   string // base URL of the API, e.g. http://localhost:5000
+	Key   string // API key, optional for a private server
+	Model string // model of the OpenAI-compatible endpoint
+
+	// used by the Stage
+	Batch    int       // cues per request
+	Context  int       // neighboring cues sent before and after the batch
+	Glossary *Glossary // terms kept consistent across the requests
+}
+
+// Factory creates a Translator of an engine
+type Factory func(cfg Config) (Translator, error)
+
+var engines = map[string]Factory{}
+
+// Register makes an engine available by the name, it panics if the name is registered twice
+func Register(name string, factory Factory) {
+	if _, ok := engines[name]; ok {
+		panic("translate: engine registered twice: " + name)
+	}
+	engines[name] = factory
+}
+
+// Engines returns the sorted names of the registered engines
+func Engines() []string {
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the Translator of cfg.Engine
+func New(cfg Config) (Translator, error) {
+	factory, ok := engines[cfg.Engine]
+	if !ok {
+		return nil, fmt.Errorf("unknown translate engine: %s, available engines: %s", cfg.Engine, strings.Join(Engines(), ", "))
+	}
+	return factory(cfg)
+}
+
+// chainOf splits the comma separated engines
+func chainOf(engine string) []string {
+	var names []string
+	for _, name := range strings.Split(engine, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// HasEngine reports whether the comma separated chain contains the engine
+func HasEngine(chain, name string) bool {
+	for _, n := range chainOf(chain) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckChain checks all the engines in the comma separated chain are registered
+func CheckChain(engine string) error {
+	names := chainOf(engine)
+	if len(names) == 0 {
+		return errors.New("no translate engine")
+	}
+	for _, name := range names {
+		if _, ok := engines[name]; !ok {
+			return fmt.Errorf("unknown translate engine: %s, available engines: %s", name, strings.Join(Engines(), ", "))
+		}
+	}
+	return nil
+}
+
+// NewChain creates the Translators of the comma separated cfg.Engine, e.g. openai,google,
+// the first one is the primary and the others are the fallbacks in order.
+func NewChain(cfg Config) ([]Translator, error) {
+	if err := CheckChain(cfg.Engine); err != nil {
+		return nil, err
+	}
+	var chain []Translator
+	for _, name := range chainOf(cfg.Engine) {
+		c := cfg
+		c.Engine = name
+		tr, err := New(c)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, tr)
+	}
+	return chain, nil
+}
+
+// IsChinese reports whether the language is a variant of Chinese
+func IsChinese(lang string) bool {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(lang, "_", "-")), "-")
+	return primary == "zh" || primary == "yue"
+}
+
+// Google uses the free Google Translate web API
+type Google struct{}
+
+func (Google) Name() string {
+	return "google"
+}
+
+// googleLang converts a BCP 47 tag to the Google Translate code,
+// which only keeps the region for Chinese, e.g. en-US -> en but zh-TW -> zh-TW.
+func googleLang(lang string) string {
+	if lang == "" {
+		return "auto"
+	}
+	if IsChinese(lang) {
+		if isTraditional(lang) {
+			return "zh-TW"
+		}
+		return "zh-CN"
+	}
+	primary, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	return primary
+}
+
+// isTraditional reports whether a Chinese tag is written in traditional characters,
+// e.g. zh-TW, zh-HK or zh-Hant.
+func isTraditional(lang string) bool {
+	lang = strings.ToUpper(lang)
+	for _, s := range []string{"TW", "HK", "MO", "HANT"} {
+		if strings.Contains(lang, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (Google) Translate(text, source, target string) (string, error) {
+	return gt.Translate(text, googleLang(source), googleLang(target))
+}
+
+// Youdao uses the Youdao web API, which is reachable from mainland China
+// but only translates into Chinese.
+type Youdao struct{}
+
+func (Youdao) Name() string {
+	return "youdao"
+}
+
+func (Youdao) Translate(text, source, target string) (string, error) {
+	if !IsChinese(target) {
+		return "", fmt.Errorf("youdao: %w: %s", ErrUnsupportedLanguage, target)
+	}
+	return webapi.RranslateByYouDao(text)
+}
+
+func init() {
+	Register("google", func(Config) (Translator, error) {
+		return Google{}, nil
+	})
+	Register("youdao", func(Config) (Translator, error) {
+		return Youdao{}, nil
+	})
+	// auto keeps the old behavior: youdao in mainland China, google otherwise
+	Register("auto", func(cfg Config) (Translator, error) {
+		if cfg.IsChina && IsChinese(cfg.Target) {
+			return Youdao{}, nil
+		}
+		return Google{}, nil
+	})
+}
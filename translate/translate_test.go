@@ -0,0 +1,97 @@
+package translate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fake prefixes the target language, it fails on the text "fail"
+type fake struct{}
+
+func (fake) Name() string {
+	return "fake"
+}
+
+func (fake) Translate(text, source, target string) (string, error) {
+	if text == "fail" {
+		return "", errors.New("fake failure")
+	}
+	return "[" + target + "] " + text, nil
+}
+
+func init() {
+	Register("fake", func(Config) (Translator, error) {
+		return fake{}, nil
+	})
+}
+
+func TestNew(t *testing.T) {
+	tr, err := New(Config{Engine: "fake", Target: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, _ := tr.Translate("hello", "ja", "en"); ret != "[en] hello" {
+		t.Errorf("fake translate: %q", ret)
+	}
+	if _, err := New(Config{Engine: "nope"}); err == nil || !strings.Contains(err.Error(), "fake") {
+		t.Errorf("unknown engine should list the available engines: %v", err)
+	}
+
+	for _, c := range []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Engine: "auto", Target: "zh-CN", IsChina: true}, "youdao"},
+		{Config{Engine: "auto", Target: "en", IsChina: true}, "google"},
+		{Config{Engine: "auto", Target: "zh-CN"}, "google"},
+		{Config{Engine: "youdao", Target: "zh-CN"}, "youdao"},
+	} {
+		tr, err := New(c.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tr.Name() != c.want {
+			t.Errorf("%+v: got %s, want %s", c.cfg, tr.Name(), c.want)
+		}
+	}
+}
+
+func TestNewChain(t *testing.T) {
+	chain, err := NewChain(Config{Engine: "fake, google,youdao", Target: "zh-CN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tr := range chain {
+		names = append(names, tr.Name())
+	}
+	if strings.Join(names, ",") != "fake,google,youdao" {
+		t.Errorf("chain: %v", names)
+	}
+	if err := CheckChain("google,nope"); err == nil {
+		t.Error("unknown engine in the chain should fail")
+	}
+	if err := CheckChain(" , "); err == nil {
+		t.Error("empty chain should fail")
+	}
+	if !HasEngine("openai, auto", "auto") || HasEngine("openai,autotranslate", "auto") {
+		t.Error("HasEngine should match the whole name")
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	for lang, want := range map[string]string{"": "auto", "en-US": "en", "ja": "ja", "zh": "zh-CN", "zh-TW": "zh-TW", "zh_HK": "zh-TW"} {
+		if got := googleLang(lang); got != want {
+			t.Errorf("googleLang(%q) = %s, want %s", lang, got, want)
+		}
+	}
+	for lang, want := range map[string]string{"": "auto", "en-US": "en", "ja": "ja", "zh": "zh", "zh-CN": "zh", "zh-TW": "zt", "zh_HK": "zt", "zh-Hant": "zt"} {
+		if got := libreLang(lang); got != want {
+			t.Errorf("libreLang(%q) = %s, want %s", lang, got, want)
+		}
+	}
+	if _, err := (Youdao{}).Translate("hello", "en", "ja"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("youdao into ja: %v", err)
+	}
+}
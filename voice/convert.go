@@ -4,29 +4,78 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoAudioStream is returned when the input has no audio stream at all
+	ErrNoAudioStream = errors.New("no audio stream found")
 )
 
 // AudioInfo holds audio file information
 type AudioInfo struct {
-	SampleRate  int
-	Channels    int
-	SampleWidth int
-	Samples     int64
+	SampleRate    int
+	Channels      int
+	SampleWidth   int
+	Samples       int64
+	Duration      float64 // seconds
+	Codec         string
+	ChannelLayout string
+}
+
+// AudioStream describes one audio stream of a multi-track container
+type AudioStream struct {
+	Index         int // absolute stream index in the container
+	AudioIndex    int // index among audio streams, as used by -map 0:a:N
+	Codec         string
+	SampleRate    int
+	Channels      int
+	ChannelLayout string
+	Language      string
+	Title         string
+	Duration      float64 // seconds, falls back to the container duration
+	BitsPerSample int
+	DurationTS    int64
+}
+
+func (s AudioStream) String() string {
+	desc := fmt.Sprintf("#%d %s %dHz %dch", s.AudioIndex, s.Codec, s.SampleRate, s.Channels)
+	if s.ChannelLayout != "" {
+		desc += " (" + s.ChannelLayout + ")"
+	}
+	if s.Language != "" {
+		desc += " lang=" + s.Language
+	}
+	if s.Title != "" {
+		desc += " title=" + strconv.Quote(s.Title)
+	}
+	return desc
 }
 
 // ffprobeOutput holds the JSON output from ffprobe
 type ffprobeOutput struct {
 	Streams []struct {
+		Index         int    `json:"index"`
+		CodecName     string `json:"codec_name"`
 		SampleRate    string `json:"sample_rate"`
 		Channels      int    `json:"channels"`
+		ChannelLayout string `json:"channel_layout"`
 		SampleFmt     string `json:"sample_fmt"`
 		DurationTS    int64  `json:"duration_ts"`
 		Duration      string `json:"duration"`
 		BitsPerSample int    `json:"bits_per_sample"`
+		Tags          struct {
+			Language string `json:"language"`
+			Title    string `json:"title"`
+		} `json:"tags"`
 	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
 }
 
 func exists(cmd string) (string, bool) {
@@ -38,33 +87,26 @@ func exists(cmd string) (string, bool) {
 	return path, true
 }
 
-// getAudioInfo uses ffprobe to get audio file information
-func getAudioInfo(filename string) (*AudioInfo, error) {
-	ffprobe, ok := exists("ffprobe")
-	if !ok {
-		return nil, errors.New("please install ffmpeg (ffprobe)")
-	}
-
-	cmd := exec.Command(ffprobe, "-v", "quiet", "-print_format", "json", "-show_streams", filename)
-	output, err := cmd.Output()
+// getAudioInfo uses ffprobe to get information of the selected audio stream,
+// audioStream < 0 means the first one, which is also the one extractWavAudio maps.
+func getAudioInfo(filename string, audioStream int) (*AudioInfo, error) {
+	streams, err := ListAudioStreams(filename)
 	if err != nil {
-		return nil, fmt.Errorf("ffprobe failed: %w", err)
+		return nil, err
 	}
-
-	var probe ffprobeOutput
-	if err := json.Unmarshal(output, &probe); err != nil {
-		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	if len(streams) == 0 {
+		return nil, fmt.Errorf("%s: %w", filename, ErrNoAudioStream)
 	}
-
-	if len(probe.Streams) == 0 {
-		return nil, errors.New("no audio stream found")
+	if audioStream < 0 {
+		audioStream = 0
+	}
+	if audioStream >= len(streams) {
+		return nil, fmt.Errorf("audio stream %d not found, available streams: %v", audioStream, streams)
 	}
 
-	stream := probe.Streams[0]
-
-	sampleRate, err := strconv.Atoi(stream.SampleRate)
-	if err != nil {
-		return nil, fmt.Errorf("invalid sample rate: %w", err)
+	stream := streams[audioStream]
+	if stream.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate of stream %v", stream)
 	}
 
 	// Convert bits_per_sample to bytes (sampleWidth)
@@ -78,29 +120,123 @@ func getAudioInfo(filename string) (*AudioInfo, error) {
 	var samples int64
 	if stream.DurationTS > 0 {
 		samples = stream.DurationTS
-	} else if stream.Duration != "" {
-		duration, err := strconv.ParseFloat(stream.Duration, 64)
-		if err == nil {
-			samples = int64(duration * float64(sampleRate))
-		}
+	} else if stream.Duration > 0 {
+		samples = int64(stream.Duration * float64(stream.SampleRate))
 	}
 
 	return &AudioInfo{
-		SampleRate:  sampleRate,
-		Channels:    stream.Channels,
-		SampleWidth: sampleWidth,
-		Samples:     samples,
+		SampleRate:    stream.SampleRate,
+		Channels:      stream.Channels,
+		SampleWidth:   sampleWidth,
+		Samples:       samples,
+		Duration:      stream.Duration,
+		Codec:         stream.Codec,
+		ChannelLayout: stream.ChannelLayout,
 	}, nil
 }
 
+// ListAudioStreams uses ffprobe to list all audio streams of the file
+func ListAudioStreams(filename string) ([]AudioStream, error) {
+	ffprobe, ok := exists("ffprobe")
+	if !ok {
+		return nil, errors.New("please install ffmpeg (ffprobe)")
+	}
+
+	cmd := exec.Command(ffprobe, "-v", "quiet", "-print_format", "json", "-show_streams", "-show_format", "-select_streams", "a", filename)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	formatDuration, _ := strconv.ParseFloat(probe.Format.Duration, 64)
+
+	streams := make([]AudioStream, 0, len(probe.Streams))
+	for i, stream := range probe.Streams {
+		sampleRate, _ := strconv.Atoi(stream.SampleRate)
+		duration, err := strconv.ParseFloat(stream.Duration, 64)
+		if err != nil {
+			// mkv doesn't carry per stream duration
+			duration = formatDuration
+		}
+		streams = append(streams, AudioStream{
+			Index:         stream.Index,
+			AudioIndex:    i,
+			Codec:         stream.CodecName,
+			SampleRate:    sampleRate,
+			Channels:      stream.Channels,
+			ChannelLayout: stream.ChannelLayout,
+			Language:      stream.Tags.Language,
+			Title:         stream.Tags.Title,
+			Duration:      duration,
+			BitsPerSample: stream.BitsPerSample,
+			DurationTS:    stream.DurationTS,
+		})
+	}
+	return streams, nil
+}
+
+// channelFilter builds the pan filter which keeps only one channel,
+// e.g. FC (center channel of 5.1) or c1 (second channel).
+func channelFilter(channel string) (string, error) {
+	if strings.HasPrefix(channel, "c") {
+		if _, err := strconv.Atoi(channel[1:]); err != nil {
+			return "", fmt.Errorf("invalid channel: %s", channel)
+		}
+		return "pan=mono|c0=" + channel, nil
+	}
+	channel = strings.ToUpper(channel)
+	for _, c := range channel {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("invalid channel: %s", channel)
+		}
+	}
+	return "pan=mono|c0=" + channel, nil
+}
+
+// isolateFilter builds the filter which derives a voice-emphasized mono track
+// from a stereo source, both are spectral center extraction, the
+// content panned to the center (usually dialogue) is kept while the wide
+// stereo background music is attenuated.
+func isolateFilter(mode IsolateMode) (string, error) {
+	switch mode {
+	case IsolateCenter:
+		// upmix to 5.1 and keep the derived center channel
+		return "surround=chl_out=5.1,pan=mono|c0=FC", nil
+	case IsolateDialogue:
+		// dialoguenhance outputs 3.0 with the dialogue in the center channel
+		return "dialoguenhance,pan=mono|c0=FC", nil
+	}
+	return "", fmt.Errorf("unknown isolate mode: %s", mode)
+}
+
 // for .wmv
-func extractWavAudio(filename string) (string, error) {
+func extractWavAudio(filename string, opts Options) (string, error) {
 	if ffmpeg, ok := exists("ffmpeg"); ok {
 		audio, err := os.CreateTemp("", "*.wav")
 		if err != nil {
 			return "", err
 		}
-		cmd := exec.Command(ffmpeg, "-y", "-i", filename, "-ar", "16000", "-ac", "1", audio.Name())
+		// 总是指定音轨，ffmpeg自动选择的音轨可能和getAudioInfo探测的不是同一条
+		args := []string{"-y", "-i", filename, "-map", fmt.Sprintf("0:a:%d", max(opts.AudioStream, 0))}
+		if opts.Channel != "" {
+			filter, err := channelFilter(opts.Channel)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, "-af", filter)
+		} else if opts.Isolate != IsolateNone {
+			filter, err := isolateFilter(opts.Isolate)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, "-af", filter)
+		}
+		args = append(args, "-ar", "16000", "-ac", "1", audio.Name())
+		cmd := exec.Command(ffmpeg, args...)
 		ret, err := cmd.CombinedOutput()
 		if err != nil {
 			return "", errors.New(string(ret))
@@ -110,7 +246,26 @@ func extractWavAudio(filename string) (string, error) {
 	return "", errors.New("please install ffmpeg")
 }
 
-func extractVadAudio(filename string) (string, error) {
+// preprocessFilter builds the filter chain applied before VAD,
+// the order is high-pass -> loudness normalization -> noise gate,
+// so that the gate threshold is relative to the normalized loudness.
+func preprocessFilter(opts Options) string {
+	var filters []string
+	if opts.HighPass > 0 {
+		filters = append(filters, "highpass=f="+strconv.FormatFloat(opts.HighPass, 'f', -1, 64))
+	}
+	if opts.Loudnorm {
+		// EBU R128
+		filters = append(filters, "loudnorm=I=-23:TP=-2:LRA=7")
+	}
+	if opts.NoiseGate < 0 {
+		threshold := math.Pow(10, opts.NoiseGate/20)
+		filters = append(filters, "agate=threshold="+strconv.FormatFloat(threshold, 'f', 6, 64)+":attack=5:release=100")
+	}
+	return strings.Join(filters, ",")
+}
+
+func extractVadAudio(filename string, opts Options) (string, error) {
 	if ffmpeg, ok := exists("ffmpeg"); ok {
 		audio, err := os.CreateTemp("", "*.pcm")
 		if err != nil {
@@ -118,8 +273,14 @@ func extractVadAudio(filename string) (string, error) {
 		}
 
 		fmt.Println("Vad", audio.Name())
+		args := []string{"-y", "-i", filename}
+		if filter := preprocessFilter(opts); filter != "" {
+			fmt.Println("Preprocess", filter)
+			args = append(args, "-af", filter)
+		}
 		// Only generate PCM file: 16000Hz, mono, 16-bit PCM
-		cmd := exec.Command(ffmpeg, "-y", "-i", filename, "-ar", "16000", "-ac", "1", "-f", "s16le", "-acodec", "pcm_s16le", audio.Name())
+		args = append(args, "-ar", "16000", "-ac", "1", "-f", "s16le", "-acodec", "pcm_s16le", audio.Name())
+		cmd := exec.Command(ffmpeg, args...)
 		ret, err := cmd.CombinedOutput()
 		if err != nil {
 			return "", errors.New(string(ret))
@@ -0,0 +1,108 @@
+package voice
+
+import "math"
+
+var (
+	// 人声概率参数
+	SPEECH_PROB_WINDOW      = 0.3 // 投票窗口时长(秒)
+	SPEECH_PROB_VOTE_WEIGHT = 0.7 // 投票所占权重，剩余为能量权重
+)
+
+// SpeechTrack 逐帧平滑后的人声概率时间序列
+type SpeechTrack struct {
+	FrameDuration float64   // 每帧时长(秒)
+	Prob          []float64 // 每帧人声概率，范围 [0, 1]
+}
+
+// frameOf 把时间换算成帧序号
+func (s *SpeechTrack) frameOf(t float64) int {
+	i := int(t / s.FrameDuration)
+	if i < 0 {
+		return 0
+	}
+	if i >= len(s.Prob) {
+		return len(s.Prob) - 1
+	}
+	return i
+}
+
+// At 返回t时刻的人声概率
+func (s *SpeechTrack) At(t float64) float64 {
+	if s == nil || len(s.Prob) == 0 {
+		return 0
+	}
+	return s.Prob[s.frameOf(t)]
+}
+
+// Mean 返回区域内的平均人声概率，可作为置信度
+func (s *SpeechTrack) Mean(r Region) float64 {
+	if s == nil || len(s.Prob) == 0 {
+		return 0
+	}
+	start, end := s.frameOf(r.Start), s.frameOf(r.End)
+	var sum float64
+	for _, p := range s.Prob[start : end+1] {
+		sum += p
+	}
+	return sum / float64(end-start+1)
+}
+
+// Quietest 返回区间内人声概率最低的时间点，可用作强制切分点
+func (s *SpeechTrack) Quietest(start, end float64) float64 {
+	if s == nil || len(s.Prob) == 0 {
+		return end
+	}
+	from, to := s.frameOf(start), s.frameOf(end)
+	best := to
+	for i := to; i >= from; i-- {
+		// 从后往前找，概率相同时尽量保留更长的区域
+		if s.Prob[i] < s.Prob[best] {
+			best = i
+		}
+	}
+	return float64(best) * s.FrameDuration
+}
+
+// newSpeechTrack 根据每帧的检测结果和能量计算平滑后的人声概率：
+// 概率 = 窗口内有声帧比例 * 投票权重 + 归一化能量 * 能量权重
+func newSpeechTrack(frameDuration float64, votes []bool, energies []float64) *SpeechTrack {
+	track := &SpeechTrack{
+		FrameDuration: frameDuration,
+		Prob:          make([]float64, len(votes)),
+	}
+	if len(votes) == 0 {
+		return track
+	}
+
+	// 以20%百分位作为底噪，95%百分位作为峰值归一化能量
+	floor := percentile(energies, 0.2)
+	peak := percentile(energies, 0.95)
+
+	// 前缀和计算窗口投票
+	prefix := make([]int, len(votes)+1)
+	for i, voiced := range votes {
+		prefix[i+1] = prefix[i]
+		if voiced {
+			prefix[i+1]++
+		}
+	}
+
+	half := int(SPEECH_PROB_WINDOW / frameDuration / 2)
+	for i := range votes {
+		lo, hi := i-half, i+half+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(votes) {
+			hi = len(votes)
+		}
+		vote := float64(prefix[hi]-prefix[lo]) / float64(hi-lo)
+
+		var energy float64
+		if peak > floor {
+			energy = math.Min(math.Max((energies[i]-floor)/(peak-floor), 0), 1)
+		}
+		track.Prob[i] = SPEECH_PROB_VOTE_WEIGHT*vote + (1-SPEECH_PROB_VOTE_WEIGHT)*energy
+	}
+	return track
+}
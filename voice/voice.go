@@ -1,6 +1,7 @@
 package voice
 
 import (
+	"errors"
 	"io"
 	"log"
 	"math"
@@ -40,6 +41,31 @@ const (
 	VadModeEnergy          VadMode = "energy" // 基于能量的VAD (autosub方法)
 )
 
+// IsolateMode 定义人声分离方式
+type IsolateMode string
+
+const (
+	IsolateNone     IsolateMode = ""
+	IsolateCenter   IsolateMode = "center"   // surround上混后提取中置声道
+	IsolateDialogue IsolateMode = "dialogue" // dialoguenhance对白增强后提取中置声道
+)
+
+// Options 控制音频提取和VAD检测
+type Options struct {
+	Mode        VadMode // VAD检测模式
+	AudioStream int     // 音轨序号(0:a:N)，-1 表示第一条音轨
+	Channel     string  // 只保留某个声道(如 FC 中置声道)，为空表示混音为单声道
+	// 立体声人声分离，生成的人声增强单声道同时用于VAD和切片，与Channel互斥
+	Isolate IsolateMode
+	// 是否在VAD时输出逐帧人声概率，见 Voice.SpeechProbability
+	SpeechProb bool
+
+	// VAD前的预处理，只作用于VAD使用的PCM，不影响上传的切片
+	HighPass  float64 // 高通滤波截止频率(Hz)，0 表示关闭，用于去除低频轰鸣
+	Loudnorm  bool    // EBU R128 响度标准化
+	NoiseGate float64 // 噪声门阈值(dBFS，如 -45)，0 表示关闭
+}
+
 type Region struct {
 	Start float64
 	End   float64
@@ -48,7 +74,11 @@ type Region struct {
 type Voice struct {
 	file      *os.File
 	videofile string
-	vadMode   VadMode // VAD检测模式
+	vadMode   VadMode    // VAD检测模式
+	info      *AudioInfo // 输入文件的音轨信息
+
+	speechProb bool         // 是否计算逐帧人声概率
+	track      *SpeechTrack // 最近一次VAD的人声概率
 }
 
 func New(filename string) (*Voice, error) {
@@ -56,14 +86,44 @@ func New(filename string) (*Voice, error) {
 }
 
 func NewWithMode(filename string, mode VadMode) (*Voice, error) {
+	return NewWithOptions(filename, Options{Mode: mode, AudioStream: -1})
+}
+
+func NewWithOptions(filename string, opts Options) (*Voice, error) {
+	// 先探测输入文件，没有音轨的文件直接拒绝
+	info, err := getAudioInfo(filename, opts.AudioStream)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Audio: duration %.2fs, %s %dHz, %d channels (%s)",
+		info.Duration, info.Codec, info.SampleRate, info.Channels, info.ChannelLayout)
+	if opts.AudioStream >= 0 {
+		log.Println("Using audio stream", opts.AudioStream)
+	}
+	if opts.Channel != "" {
+		log.Println("Using audio channel", opts.Channel)
+	}
+	if opts.Isolate != IsolateNone {
+		if opts.Channel != "" {
+			return nil, errors.New("vocal isolation can't be used together with channel selection")
+		}
+		// 只对立体声有效，单声道没有左右声道差异可用
+		if info.Channels != 2 {
+			log.Printf("Vocal isolation skipped: need stereo source, got %d channels", info.Channels)
+			opts.Isolate = IsolateNone
+		} else {
+			log.Println("Using vocal isolation", opts.Isolate)
+		}
+	}
+
 	// wmv may cause pcm convert problem.
-	wavFile, err := extractWavAudio(filename)
+	wavFile, err := extractWavAudio(filename, opts)
 	if err != nil {
 		return nil, err
 	}
 	filename = wavFile
 
-	pcmFileName, err := extractVadAudio(filename)
+	pcmFileName, err := extractVadAudio(filename, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -77,15 +137,34 @@ func NewWithMode(filename string, mode VadMode) (*Voice, error) {
 	return &Voice{
 		file:      pcmFile,
 		videofile: filename,
-		vadMode:   mode,
+		vadMode:   opts.Mode,
+		info:      info,
+
+		speechProb: opts.SpeechProb,
 	}, nil
 }
 
+// Info 返回输入文件的音轨信息
+func (v *Voice) Info() *AudioInfo {
+	return v.info
+}
+
+// SpeechProbability 返回最近一次Vad()计算的逐帧人声概率，未开启 Options.SpeechProb 时返回nil
+func (v *Voice) SpeechProbability() *SpeechTrack {
+	return v.track
+}
+
+// EstimateSlices 估算切片数量的下限：假设全程都有人声，每MAX_REGION_SIZE秒一个切片
+func EstimateSlices(duration float64) int {
+	return int(math.Ceil(duration / MAX_REGION_SIZE))
+}
+
 func (v *Voice) Close() {
 	log.Println("Remove tmp file" + v.file.Name())
 	os.Remove(v.file.Name())
 }
 
+// To 把每个区域切成音频文件，结果和r一一对应，切片失败的区域为空字符串
 func (v *Voice) To(r []Region) []string {
 	var lock sync.Mutex
 	var wg sync.WaitGroup
@@ -127,16 +206,11 @@ func (v *Voice) To(r []Region) []string {
 		wg.Wait()
 	}
 
-	var keys []int
-	var sortedFile []string
-	for k := range file {
-		keys = append(keys, k)
-	}
-	sort.Ints(keys)
-	for _, i := range keys {
-		sortedFile = append(sortedFile, file[i])
+	files := make([]string, len(r))
+	for i, f := range file {
+		files[i] = f
 	}
-	return sortedFile
+	return files
 }
 
 // Vad 根据设置的模式选择VAD方法
@@ -182,7 +256,9 @@ func (v *Voice) detectVoiceRegions() []Region {
 	var currentRegionStart float64
 	var isInRegion bool
 	currentTime := 0.0
-	silenceFrames := 0 // 连续无声帧计数
+	silenceFrames := 0     // 连续无声帧计数
+	var votes []bool       // 逐帧检测结果，用于人声概率
+	var energies []float64 // 逐帧能量，用于人声概率
 
 	for {
 		n, err := v.file.Read(frameBuffer)
@@ -202,6 +278,10 @@ func (v *Voice) detectVoiceRegions() []Region {
 			log.Printf("VAD process error: %v", err)
 			hasVoice = false
 		}
+		if v.speechProb {
+			votes = append(votes, hasVoice)
+			energies = append(energies, calculateRMS(frameBuffer))
+		}
 
 		// 简化逻辑：参考 Energy VAD
 		// 1. 检测到连续静音（>= MIN_SILENCE_FRAMES）就切分
@@ -269,6 +349,10 @@ func (v *Voice) detectVoiceRegions() []Region {
 		regions[i].End += REGION_OVERLAP
 	}
 
+	if v.speechProb {
+		v.track = newSpeechTrack(frameTime, votes, energies)
+	}
+
 	return regions
 }
 
@@ -310,6 +394,14 @@ func (v *Voice) detectVoiceRegionsByEnergy() []Region {
 	threshold := percentile(energies, ENERGY_THRESHOLD_PERCENTILE)
 	log.Printf("Energy threshold: %.2f (from %d chunks)", threshold, len(energies))
 
+	if v.speechProb {
+		votes := make([]bool, len(energies))
+		for i, energy := range energies {
+			votes[i] = energy > threshold
+		}
+		v.track = newSpeechTrack(chunkDuration, votes, energies)
+	}
+
 	// 识别语音区域
 	var regions []Region
 	var regionStart float64
@@ -382,7 +474,9 @@ func (v *Voice) detectVoiceRegionsWithPause() []Region {
 	var currentRegionStart float64
 	var isInRegion bool
 	currentTime := 0.0
-	silenceFrames := 0 // 连续无声帧计数
+	silenceFrames := 0     // 连续无声帧计数
+	var votes []bool       // 逐帧检测结果，用于人声概率
+	var energies []float64 // 逐帧能量，用于人声概率
 
 	const (
 		SOFT_REGION_SIZE float64 = 6 // 软限制：超过此长度考虑在停顿处切分
@@ -411,6 +505,10 @@ func (v *Voice) detectVoiceRegionsWithPause() []Region {
 			log.Printf("VAD process error: %v", err)
 			hasVoice = false
 		}
+		if v.speechProb {
+			votes = append(votes, hasVoice)
+			energies = append(energies, calculateRMS(frameBuffer))
+		}
 
 		if hasVoice {
 			if !isInRegion {
@@ -499,6 +597,10 @@ func (v *Voice) detectVoiceRegionsWithPause() []Region {
 		regions[i].End += REGION_OVERLAP
 	}
 
+	if v.speechProb {
+		v.track = newSpeechTrack(frameTime, votes, energies)
+	}
+
 	return regions
 }
 
@@ -129,7 +129,7 @@ func TestVoiceWithReduce(t *testing.T) {
 				formatTimestamp(withoutRegion.End),
 				withoutRegion.End-withoutRegion.Start)
 			files := f.To([]Region{*withoutRegion})
-			if len(files) > 0 {
+			if files[0] != "" {
 				cmd := exec.Command("ffplay", "-autoexit", "-ar", "16000", "-f", "s16le", "-acodec", "pcm_s16le", files[0])
 				cmd.Run()
 			}
@@ -144,7 +144,7 @@ func TestVoiceWithReduce(t *testing.T) {
 				formatTimestamp(withRegion.End),
 				withRegion.End-withRegion.Start)
 			files := f.To([]Region{*withRegion})
-			if len(files) > 0 {
+			if files[0] != "" {
 				cmd := exec.Command("ffplay", "-autoexit", "-ar", "16000", "-f", "s16le", "-acodec", "pcm_s16le", files[0])
 				cmd.Run()
 			}
@@ -175,3 +175,81 @@ func formatTimestamp(seconds float64) string {
 	millis := int((seconds - float64(int(seconds))) * 1000)
 	return fmt.Sprintf("%02d:%02d.%03d", minutes, secs, millis)
 }
+
+func TestSpeechTrack(t *testing.T) {
+	votes := []bool{false, false, true, true, true, true, false, false}
+	energies := []float64{1, 1, 100, 120, 110, 100, 2, 1}
+	track := newSpeechTrack(0.1, votes, energies)
+
+	if len(track.Prob) != len(votes) {
+		t.Fatalf("expected %d frames, got %d", len(votes), len(track.Prob))
+	}
+	if track.At(0.35) <= track.At(0.05) {
+		t.Errorf("voiced frame %.2f should be more probable than silence %.2f", track.At(0.35), track.At(0.05))
+	}
+	if mean := track.Mean(Region{Start: 0.2, End: 0.5}); mean < 0.5 {
+		t.Errorf("unexpected mean probability of voiced region: %.2f", mean)
+	}
+	if split := track.Quietest(0.3, 0.75); split < 0.6 {
+		t.Errorf("unexpected quietest point: %.2f", split)
+	}
+}
+
+func TestChannelFilter(t *testing.T) {
+	for _, c := range []struct {
+		channel string
+		want    string
+		ok      bool
+	}{
+		{"FC", "pan=mono|c0=FC", true},
+		{"fl", "pan=mono|c0=FL", true},
+		{"c1", "pan=mono|c0=c1", true},
+		{"c", "", false},
+		{"cx", "", false},
+		{"F-C", "", false},
+		{"FC|c0=FL", "", false},
+	} {
+		got, err := channelFilter(c.channel)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("channelFilter(%q) = %q, %v, want %q", c.channel, got, err, c.want)
+		}
+	}
+}
+
+func TestPreprocessFilter(t *testing.T) {
+	for _, c := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, ""},
+		{Options{HighPass: 80}, "highpass=f=80"},
+		{Options{HighPass: 120.5}, "highpass=f=120.5"},
+		{Options{Loudnorm: true}, "loudnorm=I=-23:TP=-2:LRA=7"},
+		{Options{NoiseGate: -40}, "agate=threshold=0.010000:attack=5:release=100"},
+		// a positive threshold is not a gate
+		{Options{NoiseGate: 3}, ""},
+		{Options{HighPass: 80, Loudnorm: true, NoiseGate: -40}, "highpass=f=80,loudnorm=I=-23:TP=-2:LRA=7,agate=threshold=0.010000:attack=5:release=100"},
+	} {
+		if got := preprocessFilter(c.opts); got != c.want {
+			t.Errorf("preprocessFilter(%+v) = %q, want %q", c.opts, got, c.want)
+		}
+	}
+}
+
+func TestIsolateFilter(t *testing.T) {
+	for _, c := range []struct {
+		mode IsolateMode
+		want string
+		ok   bool
+	}{
+		{IsolateCenter, "surround=chl_out=5.1,pan=mono|c0=FC", true},
+		{IsolateDialogue, "dialoguenhance,pan=mono|c0=FC", true},
+		{IsolateNone, "", false},
+		{"karaoke", "", false},
+	} {
+		got, err := isolateFilter(c.mode)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("isolateFilter(%q) = %q, %v, want %q", c.mode, got, err, c.want)
+		}
+	}
+}